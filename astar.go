@@ -0,0 +1,92 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// AStarSolver finds the cheapest path, counting the StepCost of every
+// location stepped onto after the start, with an A* search guided by the
+// Manhattan distance to the finish.  Since no step costs less than 1 that
+// distance never overestimates, so the path found is optimal.  When every
+// location costs the same, it is a shortest path just like BFSSolver's.
+type AStarSolver struct{}
+
+// PathCost totals the StepCost of every location on path after the first
+func PathCost(grid *Grid, path []Coord) (cost int) {
+	for i := 1; i < len(path); i++ {
+		cost += grid.At(path[i]).StepCost()
+	}
+	return
+}
+
+type astarItem struct {
+	idx      int
+	priority int // cost so far plus the estimate to the finish
+}
+
+// astarQueue is a min heap of astarItems by priority
+type astarQueue []astarItem
+
+func (q astarQueue) Len() int            { return len(q) }
+func (q astarQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q astarQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *astarQueue) Push(x interface{}) { *q = append(*q, x.(astarItem)) }
+func (q *astarQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+func manhattan(a, b Coord) int {
+	d := a.Diff(b)
+	if d.X < 0 {
+		d.X = -d.X
+	}
+	if d.Y < 0 {
+		d.Y = -d.Y
+	}
+	return d.X + d.Y
+}
+
+func (as *AStarSolver) Solve(grid *Grid) ([]Coord, error) {
+	start, finish, err := findEnds(grid)
+	if err != nil {
+		return nil, err
+	}
+	// cost[i] is the cheapest way found to i so far, from prev[i]
+	cost := make([]int, grid.Len())
+	prev := make([]int, grid.Len())
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[grid.Idx(start)] = grid.Idx(start)
+	q := &astarQueue{{grid.Idx(start), manhattan(start, finish)}}
+	for q.Len() > 0 {
+		it := heap.Pop(q).(astarItem)
+		cur := grid.CoordOf(it.idx)
+		if cur == finish {
+			return tracePath(grid, prev, finish), nil
+		}
+		if it.priority > cost[it.idx]+manhattan(cur, finish) {
+			continue // a stale entry; we've since found a cheaper way here
+		}
+		on, _ := grid.Neighbors(cur)
+		for _, n := range on {
+			l := grid.At(n)
+			if !l.Passable {
+				continue
+			}
+			i, c := grid.Idx(n), cost[it.idx]+l.StepCost()
+			if prev[i] < 0 || c < cost[i] {
+				cost[i], prev[i] = c, it.idx
+				heap.Push(q, astarItem{i, c + manhattan(n, finish)})
+			}
+		}
+	}
+	return nil, &SolveError{&BaseError{
+		fmt.Sprintf("There is no path from %s to %s", &start, &finish),
+		nil,
+	}}
+}
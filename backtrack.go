@@ -0,0 +1,246 @@
+package main
+
+import "context"
+
+// lattice views a Grid as the rooms of a classic perfect maze.  Every
+// location sharing the parity of origin is a room, and the location between
+// two orthogonally adjacent rooms is the wall that gets knocked out to join
+// them.  Locations with both coordinates off parity are pillars and are never
+// carved, so joining rooms as a spanning tree can't produce loops or open 2x2
+// blocks.
+type lattice struct {
+	g      *Grid
+	origin Coord
+}
+
+func (lt *lattice) isRoom(c Coord) bool {
+	return (c.X-lt.origin.X)%2 == 0 && (c.Y-lt.origin.Y)%2 == 0
+}
+
+// rooms returns every room on the grid in row order
+func (lt *lattice) rooms() (r []Coord) {
+	for y := lt.origin.Y % 2; y < lt.g.dims.Y; y += 2 {
+		for x := lt.origin.X % 2; x < lt.g.dims.X; x += 2 {
+			r = append(r, Coord{x, y})
+		}
+	}
+	return
+}
+
+// neighbors returns the rooms one wall away from room c
+func (lt *lattice) neighbors(c Coord) []Coord {
+	cc := coordCandidates{
+		cand: []Coord{
+			Coord{c.X - 2, c.Y},
+			Coord{c.X, c.Y - 2},
+			Coord{c.X + 2, c.Y},
+			Coord{c.X, c.Y + 2},
+		},
+	}
+	cc.filter(lt.g.Within)
+	return cc.dest
+}
+
+// wall returns the location between adjacent rooms a and b
+func (lt *lattice) wall(a, b Coord) Coord {
+	return Coord{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+}
+
+// join knocks out the wall between adjacent rooms a and b
+func (lt *lattice) join(a, b Coord) {
+	lt.g.Update(MakePassable, a, lt.wall(a, b), b)
+}
+
+// attach makes c passable without making a loop.  If c touches at most one
+// passage that's all it takes, plus carving the first orthogonal neighbor (in
+// Grid.Neighbors order) that touches exactly one other passage when c touches
+// none.  That is how a finish that falls off the lattice (e.g. the corner of
+// an even sized grid) gets connected.  A finish anywhere else, like a pillar
+// or the wall between two rooms, would join passages that are already
+// connected, so relink cuts the maze back to a tree around it.
+func (lt *lattice) attach(c Coord) {
+	if lt.g.At(c).Passable {
+		return
+	}
+	on, _ := lt.g.Neighbors(c)
+	var open int
+	for _, n := range on {
+		if lt.g.At(n).Passable {
+			open++
+		}
+	}
+	switch open {
+	case 0:
+		for _, n := range on {
+			if lt.passagesBeside(n, c) == 1 {
+				lt.g.Update(MakePassable, c, n)
+				return
+			}
+		}
+	case 1:
+		lt.g.Update(MakePassable, c)
+		return
+	}
+	lt.relink(c)
+}
+
+// passagesBeside counts the passable orthogonal neighbors of n other than c
+func (lt *lattice) passagesBeside(n, c Coord) (count int) {
+	on, _ := lt.g.Neighbors(n)
+	for _, o := range on {
+		if o != c && lt.g.At(o).Passable {
+			count++
+		}
+	}
+	return
+}
+
+// relink carves c and joins it and the rooms back into a tree.  Every other
+// location is a link between the rooms and c next to it, which is kept or
+// carved only if they aren't already connected, like KruskalCreator does.
+// Unless c is the wall between two rooms, it gets a single link to start
+// with, trying those already carved first, and the first that lets
+// everything connect is the one it keeps.
+func (lt *lattice) relink(c Coord) {
+	g := lt.g
+	around, _ := g.Neighbors(c)
+	betweenRooms := false
+	for _, n := range around {
+		betweenRooms = betweenRooms || lt.isRoom(n)
+	}
+	// c itself stands for no link at all, when it's the wall between rooms
+	firsts := []Coord{c}
+	if !betweenRooms {
+		firsts = nil
+		for _, carved := range []bool{true, false} {
+			for _, n := range around {
+				if g.At(n).Passable == carved {
+					firsts = append(firsts, n)
+				}
+			}
+		}
+	}
+	var open []bool
+	for _, first := range firsts {
+		o, ok := lt.linkTree(c, first)
+		if open == nil || ok {
+			open = o
+		}
+		if ok {
+			break
+		}
+	}
+	for i, o := range open {
+		if o && !g.AtIdx(i).Passable {
+			g.Update(MakePassable, g.CoordOf(i))
+		} else if !o && g.AtIdx(i).Passable {
+			g.Update(MakeWall, g.CoordOf(i))
+		}
+	}
+}
+
+// linkTree plans relink with first as the link to c, returning which
+// locations would be open and whether they'd all be connected
+func (lt *lattice) linkTree(c, first Coord) ([]bool, bool) {
+	g := lt.g
+	open := make([]bool, g.Len())
+	for i := range open {
+		open[i] = g.AtIdx(i).Passable
+	}
+	open[g.Idx(c)] = true
+	sets := newDisjointSets(g.Len())
+	isNode := func(n Coord) bool { return n == c || lt.isRoom(n) }
+	// join joins the rooms and c around link n if none of them are connected
+	join := func(n Coord) bool {
+		on, _ := g.Neighbors(n)
+		var nodes []int
+		for _, o := range on {
+			if isNode(o) {
+				nodes = append(nodes, sets.find(g.Idx(o)))
+			}
+		}
+		if len(nodes) < 2 {
+			return false
+		}
+		for i := range nodes {
+			for j := range nodes[:i] {
+				if nodes[i] == nodes[j] {
+					return false
+				}
+			}
+		}
+		for _, r := range nodes[1:] {
+			sets.union(r, nodes[0])
+		}
+		return true
+	}
+	around, _ := g.Neighbors(c)
+	beside := make(map[Coord]bool)
+	for _, n := range around {
+		beside[n] = true
+		if lt.isRoom(n) {
+			sets.union(g.Idx(c), g.Idx(n)) // c is the wall between them
+		}
+	}
+	if first != c {
+		if !join(first) {
+			return open, false
+		}
+		open[g.Idx(first)] = true
+	}
+	for i := range open {
+		if n := g.CoordOf(i); open[i] && !isNode(n) && n != first && !join(n) {
+			open[i] = false
+		}
+	}
+	for _, nearC := range []bool{false, true} {
+		for i := range open {
+			if n := g.CoordOf(i); !open[i] && !isNode(n) && beside[n] == nearC && join(n) {
+				open[i] = true
+			}
+		}
+	}
+	root := sets.find(g.Idx(c))
+	for _, r := range lt.rooms() {
+		if sets.find(g.Idx(r)) != root {
+			return open, false
+		}
+	}
+	return open, true
+}
+
+// BacktrackerCreator carves a perfect maze with a randomized depth first
+// search: it walks to a random unvisited room, and when it runs out of them
+// it backs up along its own path until it finds one.  This gives long, winding
+// passages with comparatively few dead ends.
+type BacktrackerCreator struct {
+	seed int64
+}
+
+func (bc *BacktrackerCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
+	rng := newRand(&bc.seed)
+	lt := lattice{g: grid, origin: start}
+	grid.Update(MakePassable, start)
+	stack := []Coord{start}
+	for len(stack) > 0 {
+		if err := abandoned(ctx); err != nil {
+			return err
+		}
+		cur := stack[len(stack)-1]
+		var nexts coordCandidates
+		nexts.cand = lt.neighbors(cur)
+		nexts.filter(func(c Coord) bool { return !grid.At(c).Passable })
+		if len(nexts.dest) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		next := nexts.dest[rng.Intn(len(nexts.dest))]
+		lt.join(cur, next)
+		stack = append(stack, next)
+	}
+	lt.attach(finish)
+	markEnds(grid, start, finish)
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
+}
@@ -1,14 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"math/rand"
+	"sort"
 	"time"
 )
 
-var logging bool
-
 type Trans Coord // translation along x and y
 
 func (t Trans) Translate(c Coord) Coord {
@@ -20,8 +19,8 @@ func (t *Trans) String() string {
 	return c.String()
 }
 
-func (t *Trans) Rand() {
-	switch rand.Intn(4) {
+func (t *Trans) Rand(rng *rand.Rand) {
+	switch rng.Intn(4) {
 	case 0:
 		*t = Trans{-1, 0}
 	case 1:
@@ -33,8 +32,126 @@ func (t *Trans) Rand() {
 	}
 }
 
+// MazeCreator carves a maze from start to finish into a grid of walls.  If it
+// can't, it returns an error, typically a *GenerationError or, when start or
+// finish are off the grid, an *OutOfBoundsError.  It gives up with a
+// *GenerationError wrapping ctx.Err() as soon as it notices ctx is done.
 type MazeCreator interface {
-	Fill(grid *Grid, start, finish Coord)
+	Fill(ctx context.Context, grid *Grid, start, finish Coord) error
+}
+
+// GenerationError reports that a MazeCreator gave up on a maze
+type GenerationError struct {
+	*BaseError
+}
+
+// abandoned returns the error a Fill gives up with once ctx is done, or nil
+// while it isn't
+func abandoned(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &GenerationError{&BaseError{
+			fmt.Sprintf("Maze generation abandoned: %s", err),
+			err,
+		}}
+	}
+	return nil
+}
+
+// recoverOutOfBounds, deferred by a Fill, turns a panic from the grid's bounds
+// checks into the error that Fill returns
+func recoverOutOfBounds(err *error) {
+	if r := recover(); r != nil {
+		if oobe, ok := r.(*OutOfBoundsError); ok {
+			*err = oobe
+			return
+		}
+		panic(r)
+	}
+}
+
+// creatorEntry describes a generation algorithm and builds its MazeCreator,
+// seeded and configured from the request so the same request always yields
+// the same maze
+type creatorEntry struct {
+	description string
+	perfect     bool // whether its mazes always have exactly one path between any two locations
+	create      func(mr *MazeRequest) MazeCreator
+}
+
+// creators maps the name of each generation algorithm, as the API takes it,
+// to its entry
+var creators = map[string]creatorEntry{
+	"walking": {
+		"A random walk that branches off when it gets stuck; mazes vary a lot in density and difficulty",
+		false,
+		func(mr *MazeRequest) MazeCreator { return &WalkingCreator{seed: mr.seed} },
+	},
+	"backtracker": {
+		"Randomized depth first search; a perfect maze of long winding passages",
+		true,
+		func(mr *MazeRequest) MazeCreator { return &BacktrackerCreator{seed: mr.seed} },
+	},
+	"kruskal": {
+		"Randomized Kruskal's algorithm; a perfect maze with many short dead ends",
+		true,
+		func(mr *MazeRequest) MazeCreator { return &KruskalCreator{seed: mr.seed} },
+	},
+	"wilson": {
+		"Wilson's algorithm; every perfect maze is equally likely",
+		true,
+		func(mr *MazeRequest) MazeCreator { return &WilsonCreator{seed: mr.seed} },
+	},
+	"eller": {
+		"Eller's algorithm; a perfect maze built one row at a time",
+		true,
+		func(mr *MazeRequest) MazeCreator { return &EllerCreator{seed: mr.seed} },
+	},
+	"division": {
+		"Recursive division; a perfect maze of long straight corridors",
+		true,
+		func(mr *MazeRequest) MazeCreator { return &RecursiveDivisionCreator{seed: mr.seed} },
+	},
+	"growingtree": {
+		"Growing tree; a perfect maze whose texture is set by the policy parameter, e.g. newest:75,random:25",
+		true,
+		func(mr *MazeRequest) MazeCreator {
+			return &GrowingTreeCreator{seed: mr.seed, policy: mr.policy}
+		},
+	},
+}
+
+// creatorNames returns the names of all the creators, sorted
+func creatorNames() []string {
+	names := make([]string, 0, len(creators))
+	for n := range creators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const defaultCreator = "walking"
+
+// newRand returns a random source of its own for one run of a creator, so
+// concurrent runs can't disturb each other's sequence.  A zero seed is first
+// replaced with one from the clock.
+func newRand(seed *int64) *rand.Rand {
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(*seed))
+}
+
+// markEnds flags the start and finish locations so renderers can label them
+func markEnds(grid *Grid, start, finish Coord) {
+	grid.Update(func(l Loc) Loc {
+		l.Special = l.Special | Start
+		return l
+	}, start)
+	grid.Update(func(l Loc) Loc {
+		l.Special = l.Special | Finish
+		return l
+	}, finish)
 }
 
 type WalkingCreator struct {
@@ -42,6 +159,7 @@ type WalkingCreator struct {
 }
 
 type walkingCreatorRun struct {
+	rng               *rand.Rand
 	g                 *Grid
 	finish            Coord
 	start             Coord
@@ -55,13 +173,9 @@ func (r *walkingCreatorRun) previousCandidateFilterFactory(
 ) func(Coord) bool { //Is this neighbor a possible previous?  We're tracing backwards to solve the maze
 	return func(c Coord) (b bool) {
 		var msg string
-		if logging {
-			defer func() {
-			log.Printf("Cur %s: Filter result for %s: %t (%s)",
-				cur.String(),
-				c.String(), b, msg)
-			}()
-		}
+		defer func() {
+			r.g.note(Event{Step{c, StepCandidate}, cur, b, msg})
+		}()
 		if r.g.At(c).Passable {
 			// if we're working backwards and we've found a passable  candidate, we've
 			// complated the maze.
@@ -114,11 +228,9 @@ func (r *walkingCreatorRun) nextCandidateFilterFactory(
 ) func(Coord) bool {
 	return func(c Coord) (b bool) { //Is this neighbor a possible next?
 		var msg string
-		if logging { defer func() {
-			log.Printf("Cur %s: Filter result for %s: %t (%s)",
-				cur.String(),
-				c.String(), b, msg)
-		}() }
+		defer func() {
+			r.g.note(Event{Step{c, StepCandidate}, cur, b, msg})
+		}()
 		if c == r.finish {
 			msg = "it's the finish (reached set to true)"
 			r.reached = true
@@ -151,22 +263,14 @@ func (r *walkingCreatorRun) nextCandidateFilterFactory(
 	}
 }
 
-func (wc *WalkingCreator) Fill(grid *Grid, start, finish Coord) {
+func (wc *WalkingCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
 	var max_passes = grid.Len() * 12
-	if wc.seed == 0 {
-		wc.seed = time.Now().UnixNano()
-	}
-	rand.Seed(wc.seed)
+	rng := newRand(&wc.seed)
 	grid.Update(MakePassable, start, finish)
-	grid.Update(func(l Loc) Loc {
-		l.Special = l.Special | Start
-		return l
-	}, start)
-	grid.Update(func(l Loc) Loc {
-		l.Special = l.Special | Finish
-		return l
-	}, finish)
+	markEnds(grid, start, finish)
 	r := walkingCreatorRun{
+		rng:               rng,
 		start:             start,
 		finish:            finish,
 		g:                 grid,
@@ -174,13 +278,19 @@ func (wc *WalkingCreator) Fill(grid *Grid, start, finish Coord) {
 	}
 	cur := start
 	for !r.reached {
+		if err := abandoned(ctx); err != nil {
+			return err
+		}
 		if max_passes < -10000 {
-			log.Printf("Failed to reverse complete maze after 10000 reverse iterations")
-			// this should probably be a failure of somethign
-			return
+			return &GenerationError{&BaseError{
+				fmt.Sprintf("Failed to reverse complete %s maze (seed %d) after 10000 reverse iterations",
+					&grid.dims, wc.seed),
+				nil,
+			}}
 		} else if max_passes == 0 {
-			if logging { log.Printf("Max passes reached at %s, reverse completings", &cur) }
 			grid.Update(func(l Loc) Loc { l.Special = l.Special | MaxPasses; return l }, cur)
+			grid.note(Event{Step: Step{finish, StepReverse},
+				Reason: fmt.Sprintf("Max passes reached at %s, reverse completing", &cur)})
 			r.reverse = true
 			cur = finish
 			r.reverse_locations = append(r.reverse_locations, finish)
@@ -196,7 +306,7 @@ func (wc *WalkingCreator) Fill(grid *Grid, start, finish Coord) {
 		if len(nexts.dest) > 0 {
 			// TODO: if we're reverse solving, we should prefer candidates that complete the maze
 			// we can choose a random qualified candidate
-			next := nexts.dest[rand.Intn(len(nexts.dest))]
+			next := nexts.dest[r.rng.Intn(len(nexts.dest))]
 			grid.Update(MakePassable, next)
 			if r.reverse {
 				grid.Update(func(l Loc) Loc { l.Special = l.Special | Reverse; return l }, next)
@@ -210,24 +320,25 @@ func (wc *WalkingCreator) Fill(grid *Grid, start, finish Coord) {
 		// we don't seem to have any possible positions
 		if r.reverse {
 			if len(r.reverse_locations) == 0 {
-				panic("No reverse destinations!  This makes no sense")
+				return &GenerationError{&BaseError{"No reverse destinations!  This makes no sense", nil}}
 			}
 			last := cur
-			cur = r.reverse_locations[rand.Intn(len(r.reverse_locations))]
-			if logging {
-				log.Printf("No candidates  backwards from %s, going to previous part of backwards path %s", last.String(), cur.String())
-			}
+			cur = r.reverse_locations[r.rng.Intn(len(r.reverse_locations))]
+			grid.note(Event{Step: Step{cur, StepJump},
+				Reason: fmt.Sprintf("No candidates backwards from %s, going to previous part of backwards path", &last)})
 			continue
 		}
-		if logging { log.Printf("No candidates onward from %s", cur.String()) }
 		grid.Update(func(l Loc)Loc{l.Special = l.Special | CreateEnd; return l }, cur)
 		// so we'll "backtrack"
 		i := r.g.Idx(cur)
 		for !(i != r.g.Idx(cur) && i != r.g.Idx(start) &&
 			i != r.g.Idx(finish) && r.g.AtIdx(i).Passable) {
-			i = rand.Intn(grid.Len())
+			i = r.rng.Intn(grid.Len())
 		}
+		grid.note(Event{Step: Step{grid.CoordOf(i), StepJump},
+			Reason: fmt.Sprintf("No candidates onward from %s, backtracking", &cur)})
 		cur = grid.CoordOf(i)
-		if logging { log.Printf("Backtracking to %s: %+v", &cur, grid.At(cur)) }
 	}
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
 }
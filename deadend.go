@@ -0,0 +1,61 @@
+package main
+
+// DeadEndSolver solves a maze by dead end filling: it fills in every dead end
+// other than the start and finish, then every location that filling turned
+// into a dead end, and so on until none are left.  In a perfect maze what
+// remains is exactly the solution; in a maze with loops, the solution is the
+// shortest path through what remains.
+type DeadEndSolver struct {
+	steps []Step
+}
+
+func (ds *DeadEndSolver) Trace() []Step {
+	return ds.steps
+}
+
+func (ds *DeadEndSolver) Solve(grid *Grid) ([]Coord, error) {
+	ds.steps = nil
+	start, finish, err := findEnds(grid)
+	if err != nil {
+		return nil, err
+	}
+	// work on a copy, so that filling doesn't touch the maze
+	var open Grid
+	open.dims = grid.dims
+	open.g = append([]Loc(nil), grid.g...)
+	isDeadEnd := func(c Coord) bool {
+		if c == start || c == finish || !open.At(c).Passable {
+			return false
+		}
+		var exits int
+		on, _ := open.Neighbors(c)
+		for _, n := range on {
+			if open.At(n).Passable {
+				exits++
+			}
+		}
+		return exits <= 1
+	}
+	var deadEnds []Coord
+	for i := 0; i < open.Len(); i++ {
+		if c := open.CoordOf(i); isDeadEnd(c) {
+			deadEnds = append(deadEnds, c)
+		}
+	}
+	for len(deadEnds) > 0 {
+		c := deadEnds[0]
+		deadEnds = deadEnds[1:]
+		if !isDeadEnd(c) {
+			continue // already filled
+		}
+		open.Update(MakeWall, c)
+		ds.steps = append(ds.steps, Step{c, StepFill})
+		on, _ := open.Neighbors(c)
+		for _, n := range on {
+			if isDeadEnd(n) {
+				deadEnds = append(deadEnds, n)
+			}
+		}
+	}
+	return (&BFSSolver{}).Solve(&open)
+}
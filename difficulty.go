@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DifficultyBand is a range of Stats.Difficulty scores, from Min up to but
+// not including Max
+type DifficultyBand struct {
+	Min, Max float64
+}
+
+// difficultyBands are the named bands, in order
+var difficultyBands = []struct {
+	name string
+	band DifficultyBand
+}{
+	{"easy", DifficultyBand{0, 1.75}},
+	{"medium", DifficultyBand{1.75, 2.5}},
+	{"hard", DifficultyBand{2.5, math.Inf(1)}},
+}
+
+// ParseDifficultyBand reads a named band like "easy", "medium" or "hard", or a
+// range of scores like "2-3.5".  Either end of a range may be left off, as in
+// "3-" for anything from 3 up.
+func ParseDifficultyBand(s string) (DifficultyBand, error) {
+	for _, b := range difficultyBands {
+		if s == b.name {
+			return b.band, nil
+		}
+	}
+	i := strings.Index(s, "-")
+	if i < 0 {
+		return DifficultyBand{}, fmt.Errorf(
+			"Difficulty invalid: %q is not easy, medium, hard, or a range like 2-3.5", s)
+	}
+	band := DifficultyBand{0, math.Inf(1)}
+	for _, end := range []struct {
+		s string
+		v *float64
+	}{
+		{s[:i], &band.Min},
+		{s[i+1:], &band.Max},
+	} {
+		if end.s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(end.s, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return band, fmt.Errorf("Difficulty invalid: %s is not a non-negative number", end.s)
+		}
+		*end.v = v
+	}
+	if band.Min >= band.Max {
+		return band, fmt.Errorf("Difficulty invalid: %q is an empty range", s)
+	}
+	return band, nil
+}
+
+// String is the name of b if it has one, or else its range, which
+// ParseDifficultyBand reads back
+func (b DifficultyBand) String() string {
+	for _, nb := range difficultyBands {
+		if b == nb.band {
+			return nb.name
+		}
+	}
+	s := strconv.FormatFloat(b.Min, 'g', -1, 64) + "-"
+	if !math.IsInf(b.Max, 1) {
+		s += strconv.FormatFloat(b.Max, 'g', -1, 64)
+	}
+	return s
+}
+
+// Contains says whether score is in b
+func (b DifficultyBand) Contains(score float64) bool {
+	return score >= b.Min && score < b.Max
+}
+
+// DifficultyError reports that no seed could be found for a maze in the
+// requested difficulty band
+type DifficultyError struct {
+	*BaseError
+}
+
+// seedSearchLimit is how many seeds FindSeed tries before giving up
+const seedSearchLimit = 1000
+
+// FindSeed returns the first seed, trying the requested one and then counting
+// up from it, whose maze has a difficulty in the requested band.  The same
+// request always finds the same seed, so a set of mazes of like difficulty
+// can be found again from the seeds they started with.  It returns a
+// *DifficultyError if none of seedSearchLimit seeds will do, or the error
+// from Generate if ctx is done first.
+func (mr *MazeRequest) FindSeed(ctx context.Context) (int64, error) {
+	try := *mr
+	for i := 0; i < seedSearchLimit; i++ {
+		m, err := try.Generate(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if s, err := Measure(m); err == nil && mr.difficulty.Contains(s.Difficulty) {
+			return try.seed, nil
+		}
+		try.seed = try.seed%math.MaxInt64 + 1 // seeds are positive
+	}
+	return 0, &DifficultyError{&BaseError{
+		fmt.Sprintf("None of the %d seeds from %d makes a %dx%d maze with %s difficulty",
+			seedSearchLimit, mr.seed, mr.x, mr.y, mr.difficulty),
+		nil,
+	}}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+)
+
+// RecursiveDivisionCreator builds a maze the opposite way from the other
+// creators: it starts with every room open, then splits the field in two
+// with a wall that has a single passage through it, and recurses into both
+// halves until they are a single room wide.  The result is a perfect maze
+// of long straight corridors, which reads more like rooms and hallways.
+type RecursiveDivisionCreator struct {
+	seed int64
+}
+
+type divisionRun struct {
+	ctx    context.Context
+	g      *Grid
+	origin Coord // rooms are the locations sharing its parity
+	rng    *rand.Rand
+}
+
+// roomX and roomY convert room indexes to grid coordinates
+func (dr *divisionRun) roomX(i int) int {
+	return dr.origin.X%2 + 2*i
+}
+
+func (dr *divisionRun) roomY(j int) int {
+	return dr.origin.Y%2 + 2*j
+}
+
+// divide walls off the open region of rooms from (x0,y0) to (x1,y1)
+// inclusive
+func (dr *divisionRun) divide(x0, y0, x1, y1 int) error {
+	w, h := x1-x0+1, y1-y0+1
+	if w < 2 || h < 2 {
+		return nil
+	}
+	if err := abandoned(dr.ctx); err != nil {
+		return err
+	}
+	if h > w || (h == w && dr.rng.Intn(2) == 0) {
+		// a horizontal wall below row k, with a gap at column gap
+		k, gap := y0+dr.rng.Intn(h-1), x0+dr.rng.Intn(w)
+		wy := dr.roomY(k) + 1
+		for x := dr.roomX(x0); x <= dr.roomX(x1); x++ {
+			if x != dr.roomX(gap) {
+				dr.g.Update(MakeWall, Coord{x, wy})
+			}
+		}
+		if err := dr.divide(x0, y0, x1, k); err != nil {
+			return err
+		}
+		return dr.divide(x0, k+1, x1, y1)
+	} else {
+		// a vertical wall right of column k, with a gap at row gap
+		k, gap := x0+dr.rng.Intn(w-1), y0+dr.rng.Intn(h)
+		wx := dr.roomX(k) + 1
+		for y := dr.roomY(y0); y <= dr.roomY(y1); y++ {
+			if y != dr.roomY(gap) {
+				dr.g.Update(MakeWall, Coord{wx, y})
+			}
+		}
+		if err := dr.divide(x0, y0, k, y1); err != nil {
+			return err
+		}
+		return dr.divide(k+1, y0, x1, y1)
+	}
+}
+
+func (dc *RecursiveDivisionCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
+	// these index the grid themselves, so check the ends first
+	for _, c := range []Coord{start, finish} {
+		if !grid.Within(c) {
+			return grid.oobe(c)
+		}
+	}
+	rng := newRand(&dc.seed)
+	dr := divisionRun{ctx: ctx, g: grid, origin: start, rng: rng}
+	nx := (grid.dims.X - start.X%2 + 1) / 2
+	ny := (grid.dims.Y - start.Y%2 + 1) / 2
+	// the open field is everything from the first room to the last; the
+	// rows and columns outside that are walls
+	grid.UpdateAll(func(l Loc) Loc {
+		l.Passable = l.X >= dr.roomX(0) && l.X <= dr.roomX(nx-1) &&
+			l.Y >= dr.roomY(0) && l.Y <= dr.roomY(ny-1)
+		return l
+	})
+	if err := dr.divide(0, 0, nx-1, ny-1); err != nil {
+		return err
+	}
+	lt := lattice{g: grid, origin: start}
+	lt.attach(finish)
+	markEnds(grid, start, finish)
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
+}
@@ -7,11 +7,20 @@ import (
 )
 
 type SVGRenderer struct {
-	dest  io.Writer
-	scale int // size of each location
-
+	dest     io.Writer
+	scale    int     // size of each location
+	solution []Coord // drawn over the maze, if set
+	trace    []Step  // animated over the maze, before the solution, if set
+	timeline []Step  // if set, animated instead of the maze; see drawTimeline
 }
 
+// An animated trace spends traceStepTime seconds on each step, unless that
+// would take longer than traceDuration seconds for all of them
+const (
+	traceStepTime = 0.05
+	traceDuration = 20.0
+)
+
 func (c *Coord) Ints() (int, int) {
 	return c.X, c.Y
 }
@@ -24,6 +33,11 @@ func (sr *SVGRenderer) PosInts(x, y int) (int, int) {
 func (sr *SVGRenderer) Draw(m *Maze) {
 	canvas := svg.New(sr.dest)
 	canvas.Start((m.x+2)*sr.scale, (m.y+2)*sr.scale)
+	if len(sr.timeline) > 0 {
+		sr.drawTimeline(canvas, m)
+		canvas.End()
+		return
+	}
 	canvas.Style("text/css",
 	fmt.Sprintf( `line {
   stroke-width: %d;
@@ -81,5 +95,134 @@ func (sr *SVGRenderer) Draw(m *Maze) {
 			canvas.Text(x+sr.scale/2, y+sr.scale/2, msg, textstyle+ "; dominant-baseline:middle; text-anchor:middle")
 		}
 	}
+	var solutionDelay float64
+	if len(sr.trace) > 0 {
+		solutionDelay = sr.drawTrace(canvas)
+	}
+	if len(sr.solution) > 0 {
+		xs, ys := (*Polygon)(&sr.solution).Unzip()
+		for i := range xs {
+			xs[i], ys[i] = sr.PosInts(xs[i], ys[i])
+			xs[i], ys[i] = xs[i]+sr.scale/2, ys[i]+sr.scale/2
+		}
+		style := fmt.Sprintf(
+			"fill: none; stroke: crimson; stroke-opacity: 0.7; stroke-width: %d; stroke-linecap: round; stroke-linejoin: round",
+			sr.scale/3+1)
+		if len(sr.trace) > 0 {
+			// it shows up once the trace is done
+			canvas.Polyline(xs, ys, `class="step"`, style+fmt.Sprintf("; animation-delay: %.3fs", solutionDelay))
+		} else {
+			canvas.Polyline(xs, ys, style)
+		}
+	}
 	canvas.End()
 }
+
+// stepTime is how many seconds to spend on each of n animated steps
+func stepTime(n int) float64 {
+	if d := traceDuration / float64(n); d < traceStepTime {
+		return d
+	}
+	return traceStepTime
+}
+
+// drawTimeline animates the maze being generated, from a Grid's timeline.
+// Each location is a square that turns white when it is carved (pink if it
+// was carved working back from the finish) and black again if it is walled
+// off, and a dot flashes wherever the creator jumped, or started working
+// back from.
+func (sr *SVGRenderer) drawTimeline(canvas *svg.SVG, m *Maze) {
+	step := stepTime(len(sr.timeline))
+	canvas.Style("text/css", `.step {
+  opacity: 0;
+  animation: reveal 0.1s forwards;
+}
+.carve {
+  fill: white;
+}
+.reverse {
+  fill: pink;
+}
+.wall {
+  fill: black;
+}
+.jump, .turn {
+  animation-name: flash;
+  animation-duration: 0.6s;
+}
+.jump {
+  fill: gold;
+}
+.turn {
+  fill: crimson;
+}
+@keyframes reveal {
+  to { opacity: 1; }
+}
+@keyframes flash {
+  50% { opacity: 1; }
+  to { opacity: 0; }
+}`)
+	canvas.Rect(0, 0, (m.x+2)*sr.scale, (m.y+2)*sr.scale, "fill: black")
+	for i, s := range sr.timeline {
+		x, y := sr.PosInts(s.X, s.Y)
+		delay := fmt.Sprintf("animation-delay: %.3fs", float64(i)*step)
+		switch s.Kind {
+		case StepCarve:
+			class := `class="step carve"`
+			if l, _ := m.At(s.X, s.Y); l.Special&Reverse != 0 {
+				class = `class="step carve reverse"`
+			}
+			canvas.Rect(x, y, sr.scale, sr.scale, class, delay)
+		case StepWall:
+			canvas.Rect(x, y, sr.scale, sr.scale, `class="step wall"`, delay)
+		case StepJump:
+			canvas.Circle(x+sr.scale/2, y+sr.scale/2, sr.scale/2, `class="step jump"`, delay)
+		case StepReverse:
+			canvas.Circle(x+sr.scale/2, y+sr.scale/2, sr.scale/2, `class="step turn"`, delay)
+		}
+	}
+	// and once it's all done, label the ends
+	done := fmt.Sprintf("animation-delay: %.3fs", float64(len(sr.timeline))*step)
+	i, _ := m.Iter()
+	for loc := range i {
+		var x, y = sr.PosInts(loc.X, loc.Y)
+		for _, end := range []struct {
+			flag uint
+			msg  string
+		}{{Start, "S"}, {Finish, "F"}} {
+			if loc.Special&end.flag != 0 {
+				// svgo writes each style as its own attribute, so they go in one
+				canvas.Text(x+sr.scale/2, y+sr.scale/2, end.msg, `class="step"`,
+					fmt.Sprintf("%s; font-size: %d; fill: green; dominant-baseline:middle; text-anchor:middle", done, sr.scale/2-1))
+			}
+		}
+	}
+}
+
+// drawTrace adds a dot for each step of the trace, appearing one after the
+// other with CSS animations so the file stays self contained.  It returns how
+// many seconds it takes for all of them to appear.
+func (sr *SVGRenderer) drawTrace(canvas *svg.SVG) float64 {
+	step := stepTime(len(sr.trace))
+	canvas.Style("text/css", `.step {
+  opacity: 0;
+  animation: reveal 0.3s forwards;
+}
+.move {
+  fill: gold;
+}
+.fill {
+  fill: dimgray;
+}
+@keyframes reveal {
+  to { opacity: 0.8; }
+}`)
+	for i, s := range sr.trace {
+		x, y := sr.PosInts(s.X, s.Y)
+		canvas.Circle(x+sr.scale/2, y+sr.scale/2, sr.scale*2/5,
+			fmt.Sprintf(`class="step %s"`, s.Kind),
+			fmt.Sprintf("animation-delay: %.3fs", float64(i)*step))
+	}
+	return float64(len(sr.trace)) * step
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+)
+
+// EllerCreator carves a perfect maze with Eller's algorithm, which finishes
+// the maze one row at a time and only needs to remember which set each room
+// in the current row belongs to.  Stream makes use of that to generate mazes
+// far too tall to hold in a Grid; Fill generates into a Grid like any other
+// MazeCreator.
+type EllerCreator struct {
+	seed int64
+}
+
+// RowStreamer is implemented by creators that can emit a maze row by row,
+// without ever holding all of it, for renderers like
+// ConsoleRenderer.DrawStream.
+type RowStreamer interface {
+	Stream(dims Dims, row func([]Loc))
+}
+
+type ellerRun struct {
+	dims    Dims
+	origin  Coord // rooms are the locations sharing its parity
+	sets    []int // set of each room in the current room row, 0 for none yet
+	down    []bool
+	nextSet int
+	rng     *rand.Rand
+}
+
+func (er *ellerRun) isRoomX(x int) bool {
+	return (x-er.origin.X)%2 == 0
+}
+
+func (er *ellerRun) row(y int) []Loc {
+	row := make([]Loc, er.dims.X)
+	for x := range row {
+		row[x].Coord = Coord{x, y}
+	}
+	return row
+}
+
+// roomRow finishes the row of rooms at y: it joins rooms across, and decides
+// which of them continue down into the next row of rooms
+func (er *ellerRun) roomRow(y int) []Loc {
+	row := er.row(y)
+	last := y+2 >= er.dims.Y
+	for i := range er.sets {
+		if er.sets[i] == 0 {
+			er.nextSet++
+			er.sets[i] = er.nextSet
+		}
+	}
+	for x := er.origin.X % 2; x < er.dims.X; x += 2 {
+		row[x].Passable = true
+		i := x / 2
+		if x+2 >= er.dims.X || er.sets[i] == er.sets[i+1] {
+			continue
+		}
+		// on the last row, everything still apart has to be joined
+		if last || er.rng.Intn(2) == 0 {
+			row[x+1].Passable = true
+			merged := er.sets[i+1]
+			for j := range er.sets {
+				if er.sets[j] == merged {
+					er.sets[j] = er.sets[i]
+				}
+			}
+		}
+	}
+	for i := range er.down {
+		er.down[i] = false
+	}
+	if last {
+		return row
+	}
+	// every set needs at least one way down, or it would be cut off
+	members := make(map[int][]int)
+	for i, s := range er.sets {
+		members[s] = append(members[s], i)
+	}
+	for i, s := range er.sets {
+		if m := members[s]; m[0] == i {
+			er.down[m[er.rng.Intn(len(m))]] = true
+		}
+	}
+	for i := range er.down {
+		if !er.down[i] && er.rng.Intn(3) == 0 {
+			er.down[i] = true
+		}
+	}
+	for i := range er.sets {
+		if !er.down[i] {
+			er.sets[i] = 0
+		}
+	}
+	return row
+}
+
+// wallRow is the row between two rows of rooms
+func (er *ellerRun) wallRow(y int) []Loc {
+	row := er.row(y)
+	for x := er.origin.X % 2; x < er.dims.X; x += 2 {
+		row[x].Passable = er.down[x/2]
+	}
+	return row
+}
+
+func (ec *EllerCreator) run(ctx context.Context, dims Dims, origin Coord, emit func([]Loc)) error {
+	rng := newRand(&ec.seed)
+	rooms := (dims.X - origin.X%2 + 1) / 2
+	er := ellerRun{
+		dims:   dims,
+		origin: origin,
+		sets:   make([]int, rooms),
+		down:   make([]bool, rooms),
+		rng:    rng,
+	}
+	for y := 0; y < dims.Y; y++ {
+		if err := abandoned(ctx); err != nil {
+			return err
+		}
+		if (y-origin.Y)%2 == 0 {
+			emit(er.roomRow(y))
+		} else {
+			emit(er.wallRow(y))
+		}
+	}
+	return nil
+}
+
+func (ec *EllerCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
+	// these index the grid themselves, so check the ends first
+	for _, c := range []Coord{start, finish} {
+		if !grid.Within(c) {
+			return grid.oobe(c)
+		}
+	}
+	if err := ec.run(ctx, grid.dims, start, func(row []Loc) {
+		for _, l := range row {
+			grid.set(grid.Idx(l.Coord), l)
+		}
+	}); err != nil {
+		return err
+	}
+	lt := lattice{g: grid, origin: start}
+	lt.attach(finish)
+	markEnds(grid, start, finish)
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
+}
+
+// Stream generates a maze with the start in the upper left corner and the
+// finish in the lower right, passing each row to row as soon as it is done.
+func (ec *EllerCreator) Stream(dims Dims, row func([]Loc)) {
+	ec.run(context.Background(), dims, Coord{0, 0}, func(r []Loc) {
+		if r[0].Y == 0 {
+			r[0].Special |= Start
+		}
+		if r[0].Y == dims.Y-1 {
+			// what lattice.attach does, for the corner
+			f := dims.X - 1
+			if !r[f].Passable {
+				r[f].Passable = true
+				if f%2 == 1 && r[0].Y%2 == 1 {
+					r[f-1].Passable = true
+				}
+			}
+			r[f].Special |= Finish
+		}
+		row(r)
+	})
+}
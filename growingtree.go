@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// GrowthPolicy weights the ways GrowingTreeCreator can choose which active
+// room to grow from next.  Always taking the newest gives the long corridors
+// of BacktrackerCreator, always taking a random one gives the short dead ends
+// of Prim's algorithm, and mixing them gives something in between.  The zero
+// value means Newest.
+type GrowthPolicy struct {
+	Newest, Random, Oldest int
+}
+
+// maxPolicyWeight bounds the weights of a policy, which are summed to pick
+// with, so they can't add up to more than an int holds
+const maxPolicyWeight = 1000
+
+// ParseGrowthPolicy reads a policy like "newest" or "newest:75,random:25".
+// A pick without a weight has weight 1, the weights can add up to at most
+// maxPolicyWeight, and the empty string is the zero policy.
+func ParseGrowthPolicy(s string) (GrowthPolicy, error) {
+	var p GrowthPolicy
+	if s == "" {
+		return p, nil
+	}
+	for _, term := range strings.Split(s, ",") {
+		var name, weight = term, "1"
+		if i := strings.Index(term, ":"); i >= 0 {
+			name, weight = term[:i], term[i+1:]
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 || w > maxPolicyWeight {
+			return p, fmt.Errorf("Policy weight invalid: %s is not an int from 0 to %d", weight, maxPolicyWeight)
+		}
+		switch name {
+		case "newest":
+			p.Newest += w
+		case "random":
+			p.Random += w
+		case "oldest":
+			p.Oldest += w
+		default:
+			return p, fmt.Errorf("Policy invalid: %q is not one of newest, random, or oldest", name)
+		}
+		if p.total() > maxPolicyWeight {
+			return p, fmt.Errorf("Policy invalid: %q has weights adding up to more than %d", s, maxPolicyWeight)
+		}
+	}
+	if p.total() == 0 {
+		return p, fmt.Errorf("Policy invalid: %q has no positive weights", s)
+	}
+	return p, nil
+}
+
+// String is the canonical form of p, which ParseGrowthPolicy reads back
+func (p GrowthPolicy) String() string {
+	var terms []string
+	for _, t := range []struct {
+		n string
+		w int
+	}{
+		{"newest", p.Newest},
+		{"random", p.Random},
+		{"oldest", p.Oldest},
+	} {
+		if t.w > 0 {
+			terms = append(terms, fmt.Sprintf("%s:%d", t.n, t.w))
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+func (p GrowthPolicy) total() int {
+	return p.Newest + p.Random + p.Oldest
+}
+
+// pick returns the index of the next of n active rooms to grow from
+func (p GrowthPolicy) pick(rng *rand.Rand, n int) int {
+	if p.total() == 0 {
+		return n - 1
+	}
+	switch r := rng.Intn(p.total()); {
+	case r < p.Newest:
+		return n - 1
+	case r < p.Newest+p.Random:
+		return rng.Intn(n)
+	default:
+		return 0
+	}
+}
+
+// GrowingTreeCreator carves a perfect maze by keeping a list of active rooms:
+// it picks one by its policy, joins it to a random unvisited neighbor which
+// then becomes active too, and retires it once it has no unvisited neighbors.
+type GrowingTreeCreator struct {
+	seed   int64
+	policy GrowthPolicy
+}
+
+func (gc *GrowingTreeCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
+	rng := newRand(&gc.seed)
+	lt := lattice{g: grid, origin: start}
+	grid.Update(MakePassable, start)
+	active := []Coord{start}
+	for len(active) > 0 {
+		if err := abandoned(ctx); err != nil {
+			return err
+		}
+		i := gc.policy.pick(rng, len(active))
+		var nexts coordCandidates
+		nexts.cand = lt.neighbors(active[i])
+		nexts.filter(func(c Coord) bool { return !grid.At(c).Passable })
+		if len(nexts.dest) == 0 {
+			active = append(active[:i], active[i+1:]...)
+			continue
+		}
+		next := nexts.dest[rng.Intn(len(nexts.dest))]
+		lt.join(active[i], next)
+		active = append(active, next)
+	}
+	lt.attach(finish)
+	markEnds(grid, start, finish)
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import "context"
+
+// disjointSets is a union-find over grid indexes
+type disjointSets []int
+
+func newDisjointSets(n int) disjointSets {
+	ds := make(disjointSets, n)
+	for i := range ds {
+		ds[i] = i
+	}
+	return ds
+}
+
+func (ds disjointSets) find(i int) int {
+	for ds[i] != i {
+		ds[i] = ds[ds[i]] // path halving
+		i = ds[i]
+	}
+	return i
+}
+
+// union merges the sets holding i and j, and reports whether they were
+// separate to begin with
+func (ds disjointSets) union(i, j int) bool {
+	ri, rj := ds.find(i), ds.find(j)
+	if ri == rj {
+		return false
+	}
+	ds[ri] = rj
+	return true
+}
+
+// KruskalCreator carves a perfect maze with randomized Kruskal's algorithm:
+// it visits every wall between two rooms in random order, knocking it out
+// whenever the rooms on either side aren't already connected.  Since the
+// whole grid grows at once, this gives lots of short dead ends rather than
+// the long corridors of BacktrackerCreator.
+type KruskalCreator struct {
+	seed int64
+}
+
+type roomPair struct {
+	a, b Coord
+}
+
+func (kc *KruskalCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
+	rng := newRand(&kc.seed)
+	lt := lattice{g: grid, origin: start}
+	var walls []roomPair
+	for _, c := range lt.rooms() {
+		grid.Update(MakePassable, c)
+		for _, n := range lt.neighbors(c) {
+			// each wall only once, from the room above or to the left
+			if n.X > c.X || n.Y > c.Y {
+				walls = append(walls, roomPair{c, n})
+			}
+		}
+	}
+	rng.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+	sets := newDisjointSets(grid.Len())
+	for _, w := range walls {
+		if err := abandoned(ctx); err != nil {
+			return err
+		}
+		if sets.union(grid.Idx(w.a), grid.Idx(w.b)) {
+			lt.join(w.a, w.b)
+		}
+	}
+	lt.attach(finish)
+	markEnds(grid, start, finish)
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
+}
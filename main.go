@@ -92,6 +92,15 @@ type Loc struct {
 	Coord
 	Passable bool
 	Special  uint
+	Cost     int // to step onto this location; anything below 1 counts as 1
+}
+
+// StepCost is what it costs to step onto l
+func (l Loc) StepCost() int {
+	if l.Cost < 1 {
+		return 1
+	}
+	return l.Cost
 }
 
 func MakePassable(l Loc) Loc {
@@ -99,6 +108,55 @@ func MakePassable(l Loc) Loc {
 	return l
 }
 
+func MakeWall(l Loc) Loc {
+	l.Passable = false
+	return l
+}
+
+// StepKind says what a solver or creator did at a Step
+type StepKind int
+
+const (
+	StepMove      StepKind = iota // a solver moved onto the location
+	StepFill                      // a solver ruled the location out as a dead end
+	StepCarve                     // the location became passable
+	StepWall                      // the location became a wall
+	StepJump                      // a creator got stuck and jumped here to carry on
+	StepReverse                   // a creator started working back from here
+	StepCandidate                 // a creator considered moving here
+	StepDone                      // a creator finished the maze
+)
+
+func (sk StepKind) String() string {
+	switch sk {
+	case StepMove:
+		return "move"
+	case StepFill:
+		return "fill"
+	case StepCarve:
+		return "carve"
+	case StepWall:
+		return "wall"
+	case StepJump:
+		return "jump"
+	case StepReverse:
+		return "reverse"
+	case StepCandidate:
+		return "candidate"
+	case StepDone:
+		return "done"
+	default:
+		return fmt.Sprintf("StepKind(%d)", int(sk))
+	}
+}
+
+// Step is one thing a solver did while exploring, or a creator while
+// generating
+type Step struct {
+	Coord
+	Kind StepKind
+}
+
 type OutOfBoundsError struct {
 	loc  Coord
 	dims Dims
@@ -119,8 +177,32 @@ func (g *Grid) oobe(loc Coord) *OutOfBoundsError {
 }
 
 type Grid struct {
-	g    []Loc
-	dims Dims
+	g        []Loc
+	dims     Dims
+	observer Observer
+}
+
+// SetObserver has o told about every change Update or UpdateAll makes to
+// whether a location is passable, and every other event a creator notes
+func (g *Grid) SetObserver(o Observer) {
+	g.observer = o
+}
+
+// note tells the observer, if there is one, about e
+func (g *Grid) note(e Event) {
+	if g.observer != nil {
+		g.observer.Observe(e)
+	}
+}
+
+// set replaces the location at i, noting any change to whether it's passable
+func (g *Grid) set(i int, l Loc) {
+	if l.Passable && !g.g[i].Passable {
+		g.note(Event{Step: Step{l.Coord, StepCarve}})
+	} else if !l.Passable && g.g[i].Passable {
+		g.note(Event{Step: Step{l.Coord, StepWall}})
+	}
+	g.g[i] = l
 }
 
 func (g *Grid) Len() int {
@@ -131,7 +213,14 @@ func (g *Grid) Update(f func(Loc) Loc, cs ...Coord) {
 	for _, c := range cs {
 		g.oobPanic(c)
 		i := g.Idx(c)
-		g.g[i] = f(g.g[i])
+		g.set(i, f(g.g[i]))
+	}
+}
+
+// UpdateAll applies f to every location on the grid
+func (g *Grid) UpdateAll(f func(Loc) Loc) {
+	for i := range g.g {
+		g.set(i, f(g.g[i]))
 	}
 }
 
@@ -159,8 +248,12 @@ func (g *Grid) WithinIdx(i int) (b bool) {
 	return i >= 0 && i < len(g.g)
 }
 
+// oobPanic panics with an *OutOfBoundsError if c is off the grid.  Creators
+// recover it with recoverOutOfBounds so it surfaces from Fill as an error.
 func (g *Grid) oobPanic(c Coord) {
-	g.oobPanicIdx(g.Idx(c))
+	if !g.Within(c) {
+		panic(g.oobe(c))
+	}
 }
 
 func (g *Grid) oobPanicIdx(i int) {
@@ -293,16 +386,24 @@ func (g *Grid) Neighbors(loc Coord) ([]Coord, []Coord) {
 }
 
 type Maze struct {
-	grid Grid
-	l    sync.RWMutex
-	x, y int
-}
-
-func (m *Maze) At(x, y int) Loc {
+	grid    Grid
+	l       sync.RWMutex
+	x, y    int
+	perfect bool // made by a creator of perfect mazes, so Validate checks for loops
+	// how it was made, if known, so it can be made again
+	algo   string
+	policy GrowthPolicy
+	seed   int64
+}
+
+func (m *Maze) At(x, y int) (Loc, error) {
+	if !m.grid.Within(Coord{x, y}) {
+		return Loc{}, m.grid.oobe(Coord{x, y})
+	}
 	m.l.RLock()
 	l := m.grid.g[m.grid.Idx(Coord{x, y})]
 	m.l.RUnlock()
-	return l
+	return l, nil
 }
 
 func (m *Maze) Iter() (r chan Loc, cancel chan struct{}) {
@@ -311,7 +412,7 @@ func (m *Maze) Iter() (r chan Loc, cancel chan struct{}) {
 	go func() {
 		for y := 0; y < m.y; y++ {
 			for x := 0; x < m.x; x++ {
-				l := m.At(x, y)
+				l, _ := m.At(x, y)
 				select {
 				case r <- l:
 					continue
@@ -344,47 +445,90 @@ type Renderer interface {
 }
 
 type ConsoleRenderer struct {
-	dest io.Writer
+	dest     io.Writer
+	solution []Coord // marked on the maze, if set
+	onPath   map[Coord]bool
 }
 
+const (
+	consoleBorder = "\033[1;48;5;94;38;5;94m"
+	consoleOpen   = "\033[0;m"
+	consoleClear  = "\033[0m"
+	consoleWall   = "\u2588"
+)
+
 func (cr *ConsoleRenderer) Draw(m *Maze) {
-	var bordercolor = "\033[1;48;5;94;38;5;94m"
-	var opencolor = "\033[0;m"
-	var clear = "\033[0m"
-	var wall = "\u2588"
-	// header
-	fmt.Fprintf(cr.dest, "%s\n", m.grid.dims.String())
-	fmt.Fprint(cr.dest, bordercolor, strings.Repeat(wall, m.x+2), bordercolor, clear, "\n", bordercolor, wall)
+	cr.onPath = make(map[Coord]bool, len(cr.solution))
+	for _, c := range cr.solution {
+		cr.onPath[c] = true
+	}
+	cr.header(m.grid.dims)
 	// iterate over the elements, adding prefix and suffix to each lines wiht `oldx` rolls over
 	i, _ := m.Iter()
 	var oldx int = 0
 	for loc := range i {
 		if oldx > loc.X {
-			// newline and borders
-			fmt.Fprint(cr.dest, bordercolor, wall, clear, "\n", bordercolor, wall)
+			cr.newline()
 		}
 		oldx = loc.X
-		//var s = fmt.Sprintf("\033[1;38;5;135m%s\033[0m", "\u2588")
-		var s = fmt.Sprint(bordercolor, wall)
-		if loc.Passable {
-			s = fmt.Sprint(clear, opencolor, func() string {
-				if loc.Special&Start != 0 {
-					return "\033[32m" + "S"
-				}
-				if loc.Special&Finish != 0 {
-					return "\033[32m" + "F"
-				}
-				if loc.Special&MaxPasses != 0 {
-					return "\033[38;5;219m" + "*"
-				}
-				if loc.Special&Reverse != 0 {
-					return "\033[38;5;212m" + "r"
-				}
-				return " "
-			}(), clear)
+		fmt.Fprint(cr.dest, cr.loc(loc))
+	}
+	cr.footer(m.grid.dims)
+}
+
+// DrawStream prints the maze rs generates one row at a time as the rows are
+// finished, so it never has to be held in memory all at once.
+func (cr *ConsoleRenderer) DrawStream(d Dims, rs RowStreamer) {
+	cr.header(d)
+	var first = true
+	rs.Stream(d, func(row []Loc) {
+		if !first {
+			cr.newline()
 		}
-		fmt.Fprint(cr.dest, s)
+		first = false
+		for _, loc := range row {
+			fmt.Fprint(cr.dest, cr.loc(loc))
+		}
+	})
+	cr.footer(d)
+}
+
+func (cr *ConsoleRenderer) header(d Dims) {
+	fmt.Fprintf(cr.dest, "%s\n", d.String())
+	fmt.Fprint(cr.dest, consoleBorder, strings.Repeat(consoleWall, d.X+2), consoleBorder, consoleClear, "\n", consoleBorder, consoleWall)
+}
+
+// newline and borders
+func (cr *ConsoleRenderer) newline() {
+	fmt.Fprint(cr.dest, consoleBorder, consoleWall, consoleClear, "\n", consoleBorder, consoleWall)
+}
+
+func (cr *ConsoleRenderer) footer(d Dims) {
+	fmt.Fprint(cr.dest, consoleBorder, consoleWall, consoleClear, "\n", consoleBorder, strings.Repeat(" ", d.X+2), consoleClear, "\n")
+}
+
+func (cr *ConsoleRenderer) loc(loc Loc) string {
+	//var s = fmt.Sprintf("\033[1;38;5;135m%s\033[0m", "\u2588")
+	var s = fmt.Sprint(consoleBorder, consoleWall)
+	if loc.Passable {
+		s = fmt.Sprint(consoleClear, consoleOpen, func() string {
+			if loc.Special&Start != 0 {
+				return "\033[32m" + "S"
+			}
+			if loc.Special&Finish != 0 {
+				return "\033[32m" + "F"
+			}
+			if loc.Special&MaxPasses != 0 {
+				return "\033[38;5;219m" + "*"
+			}
+			if cr.onPath[loc.Coord] {
+				return "\033[1;31m" + "\u00b7"
+			}
+			if loc.Special&Reverse != 0 {
+				return "\033[38;5;212m" + "r"
+			}
+			return " "
+		}(), consoleClear)
 	}
-	//footer
-	fmt.Fprint(cr.dest, bordercolor, wall, clear, "\n", bordercolor, strings.Repeat(" ", m.x+2), clear, "\n")
+	return s
 }
@@ -2,8 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"image/png"
+	"io"
+	"math"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -104,11 +115,17 @@ func TestMaze(t *testing.T) {
 		m := NewMaze(100, 100)
 		t.Run("RandomLoc", func(t *testing.T) {
 			x, y := rand.Intn(m.x), rand.Intn(m.y)
-			if l := m.At(x, y); l.X != x || l.Y != y {
+			if l, err := m.At(x, y); err != nil || l.X != x || l.Y != y {
 				t.Errorf("(%d,%d) did not have correct coordinates: %+v",
 					x, y, l)
 			}
 		})
+		t.Run("OutOfBounds", func(t *testing.T) {
+			var oobe *OutOfBoundsError
+			if _, err := m.At(m.x, 0); !errors.As(err, &oobe) {
+				t.Errorf("Expected an OutOfBoundsError, got %v", err)
+			}
+		})
 	})
 }
 
@@ -127,7 +144,9 @@ func TestConsoleRenderer(t *testing.T) {
 func TestWalkingCreator(t *testing.T) {
 	m := NewMaze(50, 20)
 	wc := &WalkingCreator{}
-	wc.Fill(&m.grid, Coord{0, 0}, Coord{m.x - 1, m.y - 1})
+	if err := wc.Fill(context.Background(), &m.grid, Coord{0, 0}, Coord{m.x - 1, m.y - 1}); err != nil {
+		t.Fatal(err)
+	}
 	var b bytes.Buffer
 	d := ConsoleRenderer{
 		dest: &b,
@@ -135,3 +154,1130 @@ func TestWalkingCreator(t *testing.T) {
 	d.Draw(m)
 	t.Log("\n" + string(b.Bytes()))
 }
+
+func TestPerfectCreators(t *testing.T) {
+	for _, algo := range []string{"backtracker", "kruskal", "wilson", "eller", "division"} {
+		t.Run(algo, func(t *testing.T) {
+			testPerfectCreator(t, algo, GrowthPolicy{})
+		})
+	}
+	for _, policy := range []string{"newest", "random", "oldest", "newest:75,random:25"} {
+		t.Run("growingtree/"+policy, func(t *testing.T) {
+			p, err := ParseGrowthPolicy(policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testPerfectCreator(t, "growingtree", p)
+		})
+	}
+}
+
+func TestGrowthPolicy(t *testing.T) {
+	for s, exp := range map[string]string{
+		"":                    "",
+		"newest":              "newest:1",
+		"random:25,newest:75": "newest:75,random:25",
+		"oldest:2,oldest:3":   "oldest:5",
+	} {
+		if p, err := ParseGrowthPolicy(s); err != nil {
+			t.Errorf("%q: %s", s, err)
+		} else if p.String() != exp {
+			t.Errorf("%q: expected %q, got %q", s, exp, p.String())
+		}
+	}
+	for _, s := range []string{"newest:", "newest:-1", "newest:0", "deepest", "newest,,random",
+		"newest:9223372036854775807,random:1", "newest:1001", "newest:600,random:401"} {
+		if _, err := ParseGrowthPolicy(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+	rec := httptest.NewRecorder()
+	ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/10x10/1801?algo=growingtree&policy=newest:9223372036854775807,random:1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d for an overflowing policy, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func testPerfectCreator(t *testing.T, algo string, policy GrowthPolicy) {
+	for _, dims := range []Dims{{50, 20}, {31, 17}, {3, 3}} {
+		t.Run(dims.String(), func(t *testing.T) {
+			// the corner, then a pillar, a wall between rooms, and the middle
+			for _, finish := range []Coord{{dims.X - 1, dims.Y - 1}, {1, 1}, {2, 1}, {dims.X / 2, dims.Y / 2}} {
+				m := NewMaze(dims.X, dims.Y)
+				start := Coord{0, 0}
+				mc := creators[algo].create(&MazeRequest{seed: 1801, policy: policy})
+				if err := mc.Fill(context.Background(), &m.grid, start, finish); err != nil {
+					t.Fatal(err)
+				}
+				lt := lattice{g: &m.grid, origin: start}
+				for _, c := range lt.rooms() {
+					if !m.grid.At(c).Passable {
+						t.Errorf("Finish %s: room %s was never carved", &finish, &c)
+					}
+				}
+				if l := m.grid.At(finish); !l.Passable || l.Special&Finish == 0 {
+					t.Errorf("Finish %s was not carved and flagged: %+v", &finish, l)
+				}
+				checkSpanningTree(t, &m.grid, start)
+				m.perfect = true
+				if err := Validate(m); err != nil {
+					t.Errorf("Finish %s: %s", &finish, err)
+				}
+				var b bytes.Buffer
+				d := ConsoleRenderer{
+					dest: &b,
+				}
+				d.Draw(m)
+				t.Log("\n" + string(b.Bytes()))
+			}
+		})
+	}
+}
+
+func TestEllerStream(t *testing.T) {
+	for _, dims := range []Dims{{31, 17}, {30, 16}, {4, 3}} {
+		t.Run(dims.String(), func(t *testing.T) {
+			m := NewMaze(dims.X, dims.Y)
+			if err := (&EllerCreator{seed: 1801}).Fill(context.Background(), &m.grid, Coord{0, 0}, Coord{m.x - 1, m.y - 1}); err != nil {
+				t.Fatal(err)
+			}
+			var y int
+			(&EllerCreator{seed: 1801}).Stream(dims, func(row []Loc) {
+				for x, l := range row {
+					if exp, _ := m.At(x, y); l != exp {
+						t.Errorf("Streamed %+v, filled %+v", l, exp)
+					}
+				}
+				y++
+			})
+			if y != dims.Y {
+				t.Errorf("Streamed %d rows, expected %d", y, dims.Y)
+			}
+		})
+	}
+	t.Run("DrawStream", func(t *testing.T) {
+		var b bytes.Buffer
+		d := ConsoleRenderer{
+			dest: &b,
+		}
+		d.DrawStream(Dims{21, 5001}, &EllerCreator{})
+		// dimensions, two borders, and the rows
+		if lines := bytes.Count(b.Bytes(), []byte("\n")); lines != 5001+3 {
+			t.Errorf("Expected %d lines, got %d", 5001+3, lines)
+		}
+	})
+}
+
+func TestServerMux(t *testing.T) {
+	mux := ServerMux()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+	t.Run("Algorithms", func(t *testing.T) {
+		rec := get("/api/algorithms")
+		var algos []struct {
+			Name    string
+			Default bool
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &algos); err != nil {
+			t.Fatal(err)
+		}
+		if len(algos) != len(creators) {
+			t.Errorf("Expected %d algorithms, got %d", len(creators), len(algos))
+		}
+		for _, a := range algos {
+			if a.Default != (a.Name == defaultCreator) {
+				t.Errorf("%s: default %t", a.Name, a.Default)
+			}
+		}
+	})
+	t.Run("Algo", func(t *testing.T) {
+		for _, algo := range append(creatorNames(), "") {
+			if rec := get("/api/maze/20x20/1801?algo=" + algo); rec.Code != http.StatusOK {
+				t.Errorf("%q: %d %s", algo, rec.Code, rec.Body.String())
+			}
+		}
+	})
+	t.Run("UnknownAlgo", func(t *testing.T) {
+		rec := get("/api/maze/20x20/1801?algo=prim")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+		for _, n := range creatorNames() {
+			if !strings.Contains(rec.Body.String(), n) {
+				t.Errorf("%q does not list algorithm %s", rec.Body.String(), n)
+			}
+		}
+	})
+	t.Run("SeedRedirect", func(t *testing.T) {
+		rec := get("/api/maze/20x20/0?algo=growingtree&policy=random")
+		loc := rec.Header().Get("Location")
+		if rec.Code != http.StatusSeeOther || !strings.HasPrefix(loc, "/api/maze/20x20/") ||
+			!strings.Contains(loc, "algo=growingtree") || !strings.Contains(loc, "policy=random") {
+			t.Errorf("Expected a redirect keeping the algorithm, got %d to %q", rec.Code, loc)
+		}
+	})
+}
+
+func TestFillErrors(t *testing.T) {
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			for _, ends := range [][2]Coord{
+				{{0, 0}, {10, 10}},
+				{{0, 0}, {9, -1}},
+				{{-1, 0}, {9, 9}},
+				{{10, 3}, {9, 9}},
+			} {
+				m := NewMaze(10, 10)
+				mc := creators[algo].create(&MazeRequest{seed: 1801})
+				var oobe *OutOfBoundsError
+				if err := mc.Fill(context.Background(), &m.grid, ends[0], ends[1]); !errors.As(err, &oobe) {
+					t.Errorf("%s to %s: expected an OutOfBoundsError, got %v", &ends[0], &ends[1], err)
+				}
+			}
+		})
+	}
+}
+
+func TestFillCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			m := NewMaze(10, 10)
+			mc := creators[algo].create(&MazeRequest{seed: 1801})
+			var ge *GenerationError
+			err := mc.Fill(ctx, &m.grid, Coord{0, 0}, Coord{9, 9})
+			if !errors.As(err, &ge) || !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected a GenerationError wrapping context.Canceled, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerationBudget(t *testing.T) {
+	defer func(d time.Duration) { generationBudget = d }(generationBudget)
+	generationBudget = time.Nanosecond
+	rec := httptest.NewRecorder()
+	ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/256x256/1801", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected %d with Retry-After, got %d %q", http.StatusServiceUnavailable,
+			rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+// Replaying the timeline of any creator must leave the same maze it made
+func TestTimeline(t *testing.T) {
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			var timeline Timeline
+			mr := MazeRequest{x: 30, y: 21, seed: 1801, algo: algo}
+			m, err := mr.GenerateObserved(context.Background(), &timeline)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var replay Grid
+			replay.Init(m.grid.dims)
+			for _, s := range timeline {
+				switch s.Kind {
+				case StepCarve:
+					replay.Update(MakePassable, s.Coord)
+				case StepWall:
+					replay.Update(MakeWall, s.Coord)
+				}
+			}
+			for i := 0; i < m.grid.Len(); i++ {
+				if l := m.grid.AtIdx(i); replay.AtIdx(i).Passable != l.Passable {
+					t.Errorf("Replay disagrees about %s", &l.Coord)
+				}
+			}
+		})
+	}
+	t.Run("API", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x20/1801?animate=generate", nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `class="step carve"`) {
+			t.Errorf("Expected the generation animated, got %d", rec.Code)
+		}
+		checkXML(t, rec.Body.Bytes())
+	})
+}
+
+func TestObserver(t *testing.T) {
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			var events []Event
+			mr := MazeRequest{x: 30, y: 21, seed: 1801, algo: algo}
+			_, err := mr.GenerateObserved(context.Background(), ObserverFunc(func(e Event) {
+				events = append(events, e)
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := make(map[StepKind]int)
+			for _, e := range events {
+				counts[e.Kind]++
+			}
+			if counts[StepCarve] == 0 {
+				t.Errorf("No carve events")
+			}
+			if last := events[len(events)-1]; counts[StepDone] != 1 || last.Kind != StepDone {
+				t.Errorf("Expected a single done event last, got %d ending with %+v", counts[StepDone], last)
+			}
+			// the walking creator explains every decision it makes
+			if algo == "walking" && counts[StepCandidate] == 0 {
+				t.Errorf("No candidate events")
+			}
+		})
+	}
+}
+
+type failingCreator struct{}
+
+func (failingCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) error {
+	return &GenerationError{&BaseError{"gave up", nil}}
+}
+
+func TestGenerationErrorResponse(t *testing.T) {
+	creators["failing"] = creatorEntry{"always fails", false, func(*MazeRequest) MazeCreator { return failingCreator{} }}
+	defer delete(creators, "failing")
+	rec := httptest.NewRecorder()
+	ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/10x10/1801?algo=failing", nil))
+	var body struct{ Error string }
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error != "gave up" {
+		t.Errorf("Expected a JSON error body, got %q (%v)", rec.Body.String(), err)
+	}
+}
+
+// Every algorithm must draw the same maze for the same seed, no matter how
+// many other mazes are being generated at the same time
+func TestConcurrentSeeds(t *testing.T) {
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			render := func(seed int64) []byte {
+				mr := MazeRequest{x: 40, y: 30, scale: 10, seed: seed, algo: algo}
+				rec := httptest.NewRecorder()
+				mr.RenderSVGMaze(context.Background(), rec)
+				return rec.Body.Bytes()
+			}
+			exp := render(1801)
+			var wg sync.WaitGroup
+			svgs := make([][]byte, 32)
+			for i := range svgs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					render(int64(i + 2)) // something else to interleave with
+					svgs[i] = render(1801)
+				}(i)
+			}
+			wg.Wait()
+			for i, svg := range svgs {
+				if !bytes.Equal(svg, exp) {
+					t.Errorf("Goroutine %d drew a different maze for the same seed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestBFSSolver(t *testing.T) {
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			for seed := int64(1); seed <= 5; seed++ {
+				mr := MazeRequest{x: 30, y: 21, seed: seed, algo: algo}
+				m, err := mr.Generate(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				path, err := (&BFSSolver{}).Solve(&m.grid)
+				if err != nil {
+					t.Fatalf("Seed %d: %s", seed, err)
+				}
+				checkPath(t, &m.grid, path)
+			}
+		})
+	}
+	t.Run("Shortest", func(t *testing.T) {
+		var g Grid
+		g.Init(Dims{5, 5}).UpdateAll(MakePassable)
+		markEnds(&g, Coord{0, 0}, Coord{4, 4})
+		if path, err := (&BFSSolver{}).Solve(&g); err != nil || len(path) != 9 {
+			t.Errorf("Expected a path of 9 across an open 5x5, got %v (%v)", path, err)
+		}
+	})
+	t.Run("Unsolvable", func(t *testing.T) {
+		var g Grid
+		g.Init(Dims{5, 5}).UpdateAll(MakePassable)
+		g.Update(MakeWall, Coord{0, 2}, Coord{1, 2}, Coord{2, 2}, Coord{3, 2}, Coord{4, 2})
+		markEnds(&g, Coord{0, 0}, Coord{4, 4})
+		var se *SolveError
+		if _, err := (&BFSSolver{}).Solve(&g); !errors.As(err, &se) {
+			t.Errorf("Expected a SolveError, got %v", err)
+		}
+	})
+}
+
+func TestSolutionOverlay(t *testing.T) {
+	mr := MazeRequest{x: 20, y: 20, scale: 10, seed: 1801, algo: "backtracker"}
+	m, err := mr.Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := (&BFSSolver{}).Solve(&m.grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("SVG", func(t *testing.T) {
+		var b bytes.Buffer
+		(&SVGRenderer{dest: &b, scale: 10}).Draw(m)
+		if bytes.Contains(b.Bytes(), []byte("<polyline")) {
+			t.Errorf("Drew a solution that wasn't asked for")
+		}
+		b.Reset()
+		(&SVGRenderer{dest: &b, scale: 10, solution: path}).Draw(m)
+		if n := bytes.Count(b.Bytes(), []byte("<polyline")); n != 1 {
+			t.Errorf("Expected one solution polyline, got %d", n)
+		}
+	})
+	t.Run("Console", func(t *testing.T) {
+		var b bytes.Buffer
+		(&ConsoleRenderer{dest: &b, solution: path}).Draw(m)
+		// the ends are labelled S and F instead
+		if n := bytes.Count(b.Bytes(), []byte("\u00b7")); n != len(path)-2 {
+			t.Errorf("Expected %d steps marked, got %d", len(path)-2, n)
+		}
+		t.Log("\n" + b.String())
+	})
+	t.Run("Animated", func(t *testing.T) {
+		bs := &BFSSolver{}
+		bs.Solve(&m.grid)
+		var b bytes.Buffer
+		(&SVGRenderer{dest: &b, scale: 10, solution: path, trace: bs.Trace()}).Draw(m)
+		if n := bytes.Count(b.Bytes(), []byte(`class="step move"`)); n != len(bs.Trace()) {
+			t.Errorf("Expected a dot for each of %d steps, got %d", len(bs.Trace()), n)
+		}
+		if !bytes.Contains(b.Bytes(), []byte(`class="step" style="fill: none; stroke: crimson`)) {
+			t.Errorf("Expected the solution to appear after the trace")
+		}
+	})
+	t.Run("API", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x20/1801?animate=solve&solver=deadend", nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `class="step fill"`) {
+			t.Errorf("Expected dead end filling animated, got %d", rec.Code)
+		}
+		rec = httptest.NewRecorder()
+		ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x20/1801?solution=1", nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<polyline") {
+			t.Errorf("Expected a maze with its solution, got %d", rec.Code)
+		}
+	})
+}
+
+func TestPNGRenderer(t *testing.T) {
+	mr := MazeRequest{x: 20, y: 15, scale: 10, seed: 1801, algo: "kruskal"}
+	m, err := mr.Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := (&BFSSolver{}).Solve(&m.grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onPath := make(map[Coord]bool)
+	for _, c := range path[1 : len(path)-1] {
+		onPath[c] = true
+	}
+	for _, scale := range []int{10, 2, 1} {
+		var b bytes.Buffer
+		pr := &PNGRenderer{dest: &b, scale: scale, solution: path}
+		pr.Draw(m)
+		img, err := png.Decode(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size.X != 22*scale || size.Y != 17*scale {
+			t.Fatalf("Expected a %dx%d image, got %s", 22*scale, 17*scale, size)
+		}
+		for i := 0; i < m.grid.Len(); i++ {
+			l := m.grid.AtIdx(i)
+			expected := pngPalette[pngWall]
+			switch {
+			case l.Special&Start != 0:
+				expected = pngPalette[pngStart]
+			case l.Special&Finish != 0:
+				expected = pngPalette[pngFinish]
+			case onPath[l.Coord]:
+				expected = pngPalette[pngSolution]
+			case l.Passable:
+				expected = pngPalette[pngOpen]
+			}
+			if p := pr.center(l.Coord); img.At(p.X, p.Y) != expected {
+				t.Errorf("Scale %d, %s: expected %v at %s, got %v", scale, &l.Coord, expected, p, img.At(p.X, p.Y))
+			}
+		}
+	}
+	mux := ServerMux()
+	for _, c := range []struct {
+		path, accept string
+		code         int
+		contentType  string
+	}{
+		{"/api/maze/20x15/1801?format=png&solution=1", "", http.StatusOK, "image/png"},
+		{"/api/maze/20x15/1801", "image/png,image/*;q=0.8", http.StatusOK, "image/png"},
+		{"/api/maze/20x15/1801", "image/apng,image/svg+xml,image/*", http.StatusOK, "image/svg+xml"},
+		{"/api/maze/20x15/1801?format=svg", "image/png", http.StatusOK, "image/svg+xml"},
+		{"/api/maze/20x15/1801?format=png&animate=solve", "", http.StatusBadRequest, ""},
+		{"/api/maze/256x256/1801?format=png&s=100", "", http.StatusBadRequest, ""},
+		{"/api/maze/20x15/1801?format=gif", "", http.StatusBadRequest, ""},
+	} {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != c.code || rec.Code == http.StatusOK && rec.Header().Get("Content-Type") != c.contentType {
+			t.Errorf("%s (Accept %q): expected %d %s, got %d %s", c.path, c.accept, c.code, c.contentType,
+				rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestWorksheet(t *testing.T) {
+	mux := ServerMux()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+	rec := get("/api/worksheet.pdf?count=3&size=10x12&seed=1801&answers=1&paper=letter&orientation=landscape&title=Week+3")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("%d %s", rec.Code, rec.Body.String())
+	}
+	pdf := rec.Body.Bytes()
+	for _, s := range []string{"%PDF-1.4", "/Count 6 /MediaBox [0 0 792 612]"} {
+		if !bytes.Contains(pdf, []byte(s)) {
+			t.Errorf("Expected %q in the PDF", s)
+		}
+	}
+	// every object is where the cross reference table says
+	var xref, n int
+	fmt.Sscanf(string(pdf[bytes.LastIndex(pdf, []byte("startxref")):]), "startxref\n%d", &xref)
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("No xref table at %d", xref)
+	}
+	// the catalog, page tree and font, then each page and its content
+	if fmt.Sscanf(lines[1], "0 %d", &n); n != 1+3+2*6 {
+		t.Errorf("Expected %d objects in the xref table, got %d", 1+3+2*6, n)
+	}
+	for i := 1; i < n; i++ {
+		var offset int
+		fmt.Sscanf(lines[2+i], "%d", &offset)
+		if obj := fmt.Sprintf("%d 0 obj", i); offset >= len(pdf) || !bytes.HasPrefix(pdf[offset:], []byte(obj)) {
+			t.Errorf("Object %d is not at %d", i, offset)
+		}
+	}
+	if loc := get("/api/worksheet.pdf?count=3").Header().Get("Location"); !strings.Contains(loc, "&count=3") ||
+		strings.Contains(loc, "seed=0") {
+		t.Errorf("Expected a redirect to a seeded worksheet, got %q", loc)
+	}
+	if rec := get("/api/worksheet.pdf?count=2&size=20x20&seed=1801&algo=kruskal&difficulty=medium"); rec.Code != http.StatusOK {
+		t.Errorf("Expected a worksheet of medium mazes, got %d %s", rec.Code, rec.Body.String())
+	}
+	for _, q := range []string{"paper=a3", "count=51", "count=0", "margin=200", "margin=NaN", "margin=Inf", "size=30", "orientation=up", "size=2x40"} {
+		if rec := get("/api/worksheet.pdf?seed=1801&" + q); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", q, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestTextRenderer(t *testing.T) {
+	// S . .
+	// # # .
+	// . # F
+	m := NewMaze(3, 3)
+	m.grid.Update(MakePassable, Coord{0, 0}, Coord{1, 0}, Coord{2, 0}, Coord{2, 1}, Coord{2, 2}, Coord{0, 2})
+	markEnds(&m.grid, Coord{0, 0}, Coord{2, 2})
+	path := []Coord{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}}
+	for _, c := range []struct {
+		charset  *textCharset
+		expected string
+	}{
+		{asciiCharset, `+-------+
+| S . . |
++---+ . |
+|   | F |
++---+---+
+`},
+		{boxCharset, `┌───────┐
+│ S · · │
+├───┐ · │
+│   │ F │
+└───┴───┘
+`},
+	} {
+		var b bytes.Buffer
+		(&TextRenderer{dest: &b, charset: c.charset, solution: path}).Draw(m)
+		if b.String() != c.expected {
+			t.Errorf("Expected\n%s\ngot\n%s", c.expected, b.String())
+		}
+	}
+	// a wall on its own doesn't join anything
+	pillar := NewMaze(3, 3)
+	pillar.grid.UpdateAll(MakePassable)
+	pillar.grid.Update(MakeWall, Coord{1, 1})
+	markEnds(&pillar.grid, Coord{0, 0}, Coord{2, 2})
+	var b bytes.Buffer
+	(&TextRenderer{dest: &b, charset: boxCharset}).Draw(pillar)
+	if expected := `┌───────┐
+│ S     │
+│   ■   │
+│     F │
+└───────┘
+`; b.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, b.String())
+	}
+	mux := ServerMux()
+	for _, format := range []string{"ascii", "unicode"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x15/1801?solution=1&format="+format, nil))
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+			t.Errorf("%s: %d %s", format, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n"); len(lines) != 17 {
+			t.Errorf("%s: expected 17 lines, got %d", format, len(lines))
+		}
+		if strings.Contains(rec.Body.String(), "\033") {
+			t.Errorf("%s: has escape codes", format)
+		}
+		if format == "ascii" {
+			for _, r := range rec.Body.String() {
+				if r > 0x7f {
+					t.Errorf("ascii: has %q", r)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestMazeJSON(t *testing.T) {
+	for _, algo := range creatorNames() {
+		mr := MazeRequest{x: 21, y: 15, seed: 1801, algo: algo}
+		m, err := mr.Generate(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.grid.Update(func(l Loc) Loc { l.Cost = 5; return l }, Coord{3, 4})
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rt Maze
+		if err := json.Unmarshal(b, &rt); err != nil {
+			t.Fatalf("%s: %s", algo, err)
+		}
+		if rt.x != m.x || rt.y != m.y || rt.algo != algo || rt.seed != 1801 || rt.perfect != m.perfect {
+			t.Errorf("%s: read back %dx%d %s seed %d", algo, rt.x, rt.y, rt.algo, rt.seed)
+		}
+		for i := range m.grid.g {
+			if rt.grid.g[i] != m.grid.g[i] {
+				t.Errorf("%s: read back %+v as %+v", algo, m.grid.g[i], rt.grid.g[i])
+			}
+		}
+	}
+	// a seed past 2^53 has to be a string to survive JavaScript
+	m := NewMaze(2, 1)
+	m.grid.UpdateAll(MakePassable)
+	markEnds(&m.grid, Coord{0, 0}, Coord{1, 0})
+	m.seed = 1<<62 + 1
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rt Maze
+	if err := json.Unmarshal(b, &rt); err != nil || rt.seed != m.seed || !strings.Contains(string(b), `"seed":"4611686018427387905"`) {
+		t.Errorf("seed %d written as %s read back as %d, %v", m.seed, b, rt.seed, err)
+	}
+	for name, c := range map[string]string{
+		"Version":   `{"version": 2, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "rows": [".."]}`,
+		"Rows":      `{"version": 1, "width": 2, "height": 2, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "rows": [".."]}`,
+		"Width":     `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "rows": ["..."]}`,
+		"Chars":     `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "rows": [".x"]}`,
+		"NoStart":   `{"version": 1, "width": 2, "height": 1, "finish": {"x": 1, "y": 0}, "rows": [".."]}`,
+		"NoFinish":  `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "rows": [".."]}`,
+		"Finish":    `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 2, "y": 0}, "rows": [".."]}`,
+		"WallStart": `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "rows": ["#."]}`,
+		"Same":      `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 0, "y": 0}, "rows": [".."]}`,
+		"Mark":      `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "marks": [{"x": 1, "y": 0, "flags": ["gold"]}], "rows": [".."]}`,
+		"Policy":    `{"version": 1, "width": 2, "height": 1, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "policy": "sideways", "rows": [".."]}`,
+		"NotAMaze":  `[1, 2, 3]`,
+		"Seed":      `{"version": 1, "width": 2, "height": 1, "seed": 1801, "start": {"x": 0, "y": 0}, "finish": {"x": 1, "y": 0}, "rows": [".."]}`,
+	} {
+		var m Maze
+		var fe *FormatError
+		if err := json.Unmarshal([]byte(c), &m); !errors.As(err, &fe) {
+			t.Errorf("%s: expected a *FormatError, got %v", name, err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	ServerMux().ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x15/1801?algo=wilson&format=json&solution=1", nil))
+	var body struct {
+		Version  int
+		Rows     []string
+		Solution []struct{ X, Y int }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("%d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if body.Version != mazeJSONVersion || len(body.Rows) != 15 || len(body.Solution) == 0 ||
+		body.Solution[0].X != 0 || body.Solution[0].Y != 0 {
+		t.Errorf("Unlikely maze %+v", body)
+	}
+}
+
+func TestMazeBinary(t *testing.T) {
+	policy, _ := ParseGrowthPolicy("newest:75,random:25")
+	for _, algo := range creatorNames() {
+		for _, size := range []Dims{{3, 3}, {21, 15}, {30, 40}} {
+			mr := MazeRequest{x: size.X, y: size.Y, seed: 1801, algo: algo, policy: policy}
+			m, err := mr.Generate(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := m.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a bit for each location and a small header
+			if header := len(b) - (size.X*size.Y+7)/8; header < 0 || header > 64 {
+				t.Errorf("%s %v: %d bytes is a header of %d", algo, size, len(b), header)
+			}
+			var rt Maze
+			if err := rt.UnmarshalBinary(b); err != nil {
+				t.Fatalf("%s %v: %s", algo, size, err)
+			}
+			if rt.x != m.x || rt.y != m.y || rt.algo != algo || rt.seed != 1801 ||
+				rt.perfect != m.perfect || rt.policy.String() != m.policy.String() {
+				t.Errorf("%s %v: read back %dx%d %s %s seed %d", algo, size, rt.x, rt.y, rt.algo, rt.policy, rt.seed)
+			}
+			for i := range m.grid.g {
+				want := m.grid.g[i]
+				want.Special &= Start | Finish
+				if got := rt.grid.g[i]; got.Coord != want.Coord || got.Passable != want.Passable || got.Special != want.Special {
+					t.Errorf("%s %v: read back %+v as %+v", algo, size, want, got)
+				}
+			}
+			if got, want := fmt.Sprint(Validate(&rt)), fmt.Sprint(Validate(m)); got != want {
+				t.Errorf("%s %v: read back validates as %s, not %s", algo, size, got, want)
+			}
+		}
+	}
+	var fe *FormatError
+	if _, err := NewMaze(3, 3).MarshalBinary(); !errors.As(err, &fe) {
+		t.Errorf("Expected a *FormatError writing a maze with no ends, got %v", err)
+	}
+	mr := MazeRequest{x: 3, y: 3, seed: 1801, algo: "backtracker"}
+	m, err := mr.Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := m.MarshalBinary()
+	for name, c := range map[string][]byte{
+		"Empty":     {},
+		"Magic":     []byte("ZM\x01"),
+		"Version":   append([]byte("MZ\x02"), b[3:]...),
+		"Truncated": b[:len(b)-1],
+		"Trailing":  append(append([]byte{}, b...), 0),
+		"Header":    b[:5],
+		"Zero":      {'M', 'Z', 1, 0, 3, 0, 0, 0, 0, 0, 0, 0},
+		"Huge":      {'M', 'Z', 1, 0xff, 0xff, 0xff, 0xff, 0x0f, 1},
+		"Finish":    {'M', 'Z', 1, 2, 1, 0, 0, 2, 0, 0, 0, 0, 3},
+		"Same":      {'M', 'Z', 1, 2, 1, 0, 0, 0, 0, 0, 0, 0, 3},
+		"WallStart": {'M', 'Z', 1, 2, 1, 0, 0, 1, 0, 0, 0, 0, 2},
+		"Algorithm": {'M', 'Z', 1, 2, 1, 0, 0, 1, 0, 0, 9, 'a'},
+		"Policy":    {'M', 'Z', 1, 2, 1, 0, 0, 1, 0, 0, 0, 1, 'x', 3},
+	} {
+		var m Maze
+		var fe *FormatError
+		if err := m.UnmarshalBinary(c); !errors.As(err, &fe) {
+			t.Errorf("%s: expected a *FormatError, got %v", name, err)
+		}
+	}
+}
+
+func TestAStarSolver(t *testing.T) {
+	t.Run("Uniform", func(t *testing.T) {
+		for _, algo := range creatorNames() {
+			mr := MazeRequest{x: 30, y: 21, seed: 1801, algo: algo}
+			m, err := mr.Generate(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			bfs, _ := (&BFSSolver{}).Solve(&m.grid)
+			path, err := (&AStarSolver{}).Solve(&m.grid)
+			if err != nil {
+				t.Fatalf("%s: %s", algo, err)
+			}
+			checkPath(t, &m.grid, path)
+			if len(path) != len(bfs) {
+				t.Errorf("%s: A* took %d steps, BFS %d", algo, len(path), len(bfs))
+			}
+		}
+	})
+	t.Run("Mud", func(t *testing.T) {
+		var g Grid
+		g.Init(Dims{5, 3}).UpdateAll(MakePassable)
+		g.Update(func(l Loc) Loc { l.Cost = 10; return l }, Coord{1, 1}, Coord{2, 1}, Coord{3, 1})
+		markEnds(&g, Coord{0, 1}, Coord{4, 1})
+		path, err := (&AStarSolver{}).Solve(&g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPath(t, &g, path)
+		if c := PathCost(&g, path); c != 6 {
+			t.Errorf("Expected to go around the mud for a cost of 6, got %d: %v", c, path)
+		}
+		if bfs, _ := (&BFSSolver{}).Solve(&g); len(bfs) != 5 {
+			t.Errorf("Expected BFS to go straight through the mud, got %v", bfs)
+		}
+	})
+}
+
+func TestTracingSolvers(t *testing.T) {
+	for _, name := range []string{"deadend", "left", "right"} {
+		t.Run(name, func(t *testing.T) {
+			for _, algo := range creatorNames() {
+				if algo == "walking" {
+					continue // not always a perfect maze
+				}
+				mr := MazeRequest{x: 30, y: 21, seed: 1801, algo: algo}
+				m, err := mr.Generate(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				bfs, _ := (&BFSSolver{}).Solve(&m.grid)
+				ts := solvers[name].create().(TracingSolver)
+				path, err := ts.Solve(&m.grid)
+				if err != nil {
+					t.Fatalf("%s: %s", algo, err)
+				}
+				checkPath(t, &m.grid, path)
+				// a perfect maze has only the one solution
+				if len(path) != len(bfs) {
+					t.Errorf("%s: took %d steps, BFS %d", algo, len(path), len(bfs))
+				}
+				if len(ts.Trace()) == 0 {
+					t.Errorf("%s: no trace", algo)
+				}
+			}
+		})
+	}
+	t.Run("Loop", func(t *testing.T) {
+		// a ring around a block of wall, with the start on the ring and the
+		// finish just outside it
+		var g Grid
+		g.Init(Dims{7, 5})
+		for x := 1; x <= 5; x++ {
+			g.Update(MakePassable, Coord{x, 1}, Coord{x, 3})
+		}
+		g.Update(MakePassable, Coord{1, 2}, Coord{5, 2}, Coord{6, 2})
+		markEnds(&g, Coord{3, 1}, Coord{6, 2})
+		if path, err := (&WallFollowerSolver{hand: LeftHand}).Solve(&g); err != nil {
+			t.Errorf("The left hand is on the outside wall and should get out: %s", err)
+		} else {
+			checkPath(t, &g, path)
+		}
+		ws := &WallFollowerSolver{hand: RightHand}
+		var se *SolveError
+		if _, err := ws.Solve(&g); !errors.As(err, &se) {
+			t.Errorf("Expected the right hand to circle the block, got %v", err)
+		}
+		if len(ws.Trace()) < 8 {
+			t.Errorf("Expected a trace around the ring, got %v", ws.Trace())
+		}
+		ds := &DeadEndSolver{}
+		if path, err := ds.Solve(&g); err != nil {
+			t.Error(err)
+		} else if checkPath(t, &g, path); len(ds.Trace()) != 0 {
+			t.Errorf("A ring has no dead ends to fill, but filled %v", ds.Trace())
+		}
+	})
+}
+
+func TestMeasure(t *testing.T) {
+	// S . . . .
+	// # # . # .
+	// # # . # F
+	m := NewMaze(5, 3)
+	m.grid.Update(MakePassable, Coord{0, 0}, Coord{1, 0}, Coord{2, 0}, Coord{3, 0}, Coord{4, 0},
+		Coord{2, 1}, Coord{4, 1}, Coord{2, 2}, Coord{4, 2})
+	markEnds(&m.grid, Coord{0, 0}, Coord{4, 2})
+	s, err := Measure(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Stats{
+		SolutionLength:  7,
+		Turns:           1,
+		DeadEnds:        1,
+		Junctions:       1,
+		BranchingFactor: 7.0 / 6,
+		LongestCorridor: 3,
+		Carved:          0.6,
+		River:           0.5,
+		Difficulty:      9.0 / 7,
+	}
+	if *s != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *s)
+	}
+	m.grid.Update(MakeWall, Coord{4, 1})
+	var se *SolveError
+	if _, err := Measure(m); !errors.As(err, &se) {
+		t.Errorf("Expected a *SolveError measuring a maze with no solution, got %v", err)
+	}
+	t.Run("API", func(t *testing.T) {
+		mux := ServerMux()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x20/1801/stats?algo=backtracker", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%d %s", rec.Code, rec.Body.String())
+		}
+		var s Stats
+		if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.SolutionLength < 20 || s.Carved <= 0 || s.Carved >= 1 {
+			t.Errorf("Unlikely stats %+v", s)
+		}
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/maze/20x20/0/stats", nil))
+		if loc := rec.Header().Get("Location"); !strings.Contains(loc, "/stats?") {
+			t.Errorf("Expected a redirect to the stats of a seeded maze, got %q", loc)
+		}
+	})
+}
+
+func TestDifficulty(t *testing.T) {
+	for s, expected := range map[string]DifficultyBand{
+		"easy":  {0, 1.75},
+		"hard":  {2.5, math.Inf(1)},
+		"2-3.5": {2, 3.5},
+		"3-":    {3, math.Inf(1)},
+		"-1.5":  {0, 1.5},
+	} {
+		if b, err := ParseDifficultyBand(s); err != nil {
+			t.Errorf("%q: %s", s, err)
+		} else if b != expected {
+			t.Errorf("%q: expected %+v, got %+v", s, expected, b)
+		} else if rt, _ := ParseDifficultyBand(b.String()); rt != b {
+			t.Errorf("%q: %s does not parse back", s, b)
+		}
+	}
+	for _, s := range []string{"tricky", "3", "3-2", "x-4"} {
+		if _, err := ParseDifficultyBand(s); err == nil {
+			t.Errorf("%q parsed", s)
+		}
+	}
+	mux := ServerMux()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+	for _, band := range difficultyBands {
+		t.Run(band.name, func(t *testing.T) {
+			path := "/api/maze/20x20/1801/stats?algo=kruskal&difficulty=" + band.name
+			// served as is if the seed will do, or else redirected to one that will
+			loc, rec := path, get(path)
+			if rec.Code == http.StatusSeeOther {
+				loc = rec.Header().Get("Location")
+				if again := get(path); again.Header().Get("Location") != loc {
+					t.Errorf("Searched from the same seed to %q and %q", loc, again.Header().Get("Location"))
+				}
+				rec = get(loc)
+			}
+			var s Stats
+			if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+				t.Fatalf("%s: %d %s", loc, rec.Code, rec.Body.String())
+			}
+			if !band.band.Contains(s.Difficulty) {
+				t.Errorf("%s scores %g, not %s", loc, s.Difficulty, band.name)
+			}
+		})
+	}
+	if rec := get("/api/maze/10x10/1801?difficulty=100-"); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected %d for a difficulty out of reach, got %d", http.StatusUnprocessableEntity, rec.Code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, algo := range creatorNames() {
+		t.Run(algo, func(t *testing.T) {
+			for _, dims := range []Dims{{3, 3}, {10, 10}, {21, 15}, {30, 40}} {
+				for seed := int64(1); seed <= 100; seed++ {
+					mr := MazeRequest{x: dims.X, y: dims.Y, seed: seed, algo: algo}
+					m, err := mr.Generate(context.Background())
+					if err != nil {
+						t.Fatal(err)
+					}
+					err = Validate(m)
+					var ve *ValidationError
+					if errors.As(err, &ve) {
+						// reverse completion joining the rest of the maze can open
+						// a 2x2 block where it did, but nothing else
+						err = nil
+						for _, p := range ve.Problems {
+							if !reverseBlock(m, p) {
+								err = ve
+							}
+						}
+					}
+					if err != nil {
+						t.Errorf("%s: %s", mr.Path(), err)
+					}
+				}
+			}
+		})
+	}
+	for name, c := range map[string]struct {
+		open    []Coord
+		perfect bool
+		problem string
+	}{
+		// . . .
+		// . . #
+		// # . .
+		"Block": {[]Coord{{0, 0}, {1, 0}, {2, 0}, {0, 1}, {1, 1}, {1, 2}, {2, 2}}, false, "2x2 open block at (0,0)"},
+		// . # .
+		// . # #
+		// . . .
+		"Unreached": {[]Coord{{0, 0}, {0, 1}, {0, 2}, {1, 2}, {2, 2}, {2, 0}}, false, "1 passable locations can't be reached"},
+		// . . .
+		// # # #
+		// # # .
+		"Disconnected": {[]Coord{{0, 0}, {1, 0}, {2, 0}, {2, 2}}, false, "no path from (0,0) to (2,2)"},
+		// . . .
+		// . # .
+		// . . .
+		"Loop": {[]Coord{{0, 0}, {1, 0}, {2, 0}, {0, 1}, {2, 1}, {0, 2}, {1, 2}, {2, 2}}, true, "1 loops in a perfect maze"},
+	} {
+		m := NewMaze(3, 3)
+		m.grid.Update(MakePassable, c.open...)
+		markEnds(&m.grid, Coord{0, 0}, Coord{2, 2})
+		m.perfect = c.perfect
+		var ve *ValidationError
+		if err := Validate(m); !errors.As(err, &ve) || !strings.HasPrefix(ve.Problems[0], c.problem) {
+			t.Errorf("%s: expected %q, got %v", name, c.problem, err)
+		}
+	}
+}
+
+// reverseBlock reports whether problem is a 2x2 block in m with a location
+// a WalkingCreator marked while working back from the finish
+func reverseBlock(m *Maze, problem string) bool {
+	var c Coord
+	if _, err := fmt.Sscanf(problem, "2x2 open block at (%d,%d)", &c.X, &c.Y); err != nil {
+		return false
+	}
+	for _, d := range []Coord{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		if m.grid.At(Coord{c.X + d.X, c.Y + d.Y}).Special&Reverse != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkXML asserts that b is well-formed XML, which encoding/xml doesn't
+// quite check: it lets an element have the same attribute twice
+func checkXML(t *testing.T, b []byte) {
+	t.Helper()
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("Invalid XML: %s", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			seen := make(map[xml.Name]bool)
+			for _, a := range se.Attr {
+				if seen[a.Name] {
+					t.Fatalf("Invalid XML: <%s> has %s twice", se.Name.Local, a.Name.Local)
+				}
+				seen[a.Name] = true
+			}
+		}
+	}
+}
+
+// checkPath asserts that path runs from the start of g to the finish by
+// orthogonal steps between passable locations
+func checkPath(t *testing.T, g *Grid, path []Coord) {
+	t.Helper()
+	start, finish, err := findEnds(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) == 0 || path[0] != start || path[len(path)-1] != finish {
+		t.Fatalf("Path %v does not run from %s to %s", path, &start, &finish)
+	}
+	for i, c := range path {
+		if !g.At(c).Passable {
+			t.Errorf("Path crosses wall at %s", &c)
+		}
+		if i > 0 {
+			if r, ok := path[i-1].Rel(c); !ok || (r.X != 0 && r.Y != 0) {
+				t.Errorf("Path jumps from %s to %s", &path[i-1], &c)
+			}
+		}
+	}
+}
+
+// checkSpanningTree asserts that the passable locations of g, taken as a graph
+// with an edge between each orthogonally adjacent pair, are a tree: all
+// reachable from root, with exactly one fewer edge than they have nodes.
+func checkSpanningTree(t *testing.T, g *Grid, root Coord) {
+	t.Helper()
+	var nodes, edges int
+	for i := 0; i < g.Len(); i++ {
+		l := g.AtIdx(i)
+		if !l.Passable {
+			continue
+		}
+		nodes++
+		on, _ := g.Neighbors(l.Coord)
+		for _, n := range on {
+			if g.At(n).Passable && g.Idx(n) > i {
+				edges++
+			}
+		}
+	}
+	if edges != nodes-1 {
+		t.Errorf("%d passable locations joined by %d passages is not a tree", nodes, edges)
+	}
+	seen := map[Coord]bool{root: true}
+	queue := []Coord{root}
+	for len(queue) > 0 {
+		on, _ := g.Neighbors(queue[0])
+		queue = queue[1:]
+		for _, n := range on {
+			if g.At(n).Passable && !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	if len(seen) != nodes {
+		t.Errorf("Only %d of %d passable locations are reachable from %s", len(seen), nodes, &root)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// The binary format packs a maze as small as it goes, for archiving lots of
+// them.  After the magic "MZ" and a version byte, the header is unsigned
+// varints for the width, height, start x and y, and finish x and y, a signed
+// varint for the seed, and the algorithm and growth policy, each a uvarint
+// length followed by that many bytes.  Then there's a bit for each location,
+// in the same order as the grid, set if it's passable: location i is bit i%8
+// of byte i/8.  Marks and step costs are left out; use JSON to keep them.
+const (
+	mazeBinaryMagic   = "MZ"
+	mazeBinaryVersion = 1
+	// maxBinarySide keeps a corrupt header from asking for a huge grid
+	maxBinarySide = 1 << 16
+)
+
+// MarshalBinary writes m in the binary format.  It returns a *FormatError if
+// m has no start or finish, which the format has no way to leave out.
+func (m *Maze) MarshalBinary() ([]byte, error) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	var start, finish *Coord
+	for i := 0; i < m.grid.Len(); i++ {
+		l := m.grid.AtIdx(i)
+		if l.Special&Start != 0 {
+			start = &Coord{l.X, l.Y}
+		}
+		if l.Special&Finish != 0 {
+			finish = &Coord{l.X, l.Y}
+		}
+	}
+	if start == nil || finish == nil {
+		return nil, &FormatError{&BaseError{"Maze binary invalid: it needs a start and a finish", nil}}
+	}
+	var policy string
+	if m.policy.total() > 0 {
+		policy = m.policy.String()
+	}
+	b := []byte(mazeBinaryMagic)
+	b = append(b, mazeBinaryVersion)
+	varint := make([]byte, binary.MaxVarintLen64)
+	for _, n := range []int{m.x, m.y, start.X, start.Y, finish.X, finish.Y} {
+		b = append(b, varint[:binary.PutUvarint(varint, uint64(n))]...)
+	}
+	b = append(b, varint[:binary.PutVarint(varint, m.seed)]...)
+	for _, s := range []string{m.algo, policy} {
+		b = append(b, varint[:binary.PutUvarint(varint, uint64(len(s)))]...)
+		b = append(b, s...)
+	}
+	bits := make([]byte, (m.grid.Len()+7)/8)
+	for i := 0; i < m.grid.Len(); i++ {
+		if m.grid.AtIdx(i).Passable {
+			bits[i/8] |= 1 << (i % 8)
+		}
+	}
+	return append(b, bits...), nil
+}
+
+// UnmarshalBinary reads a maze written by MarshalBinary into m, replacing
+// whatever was there.  It returns a *FormatError if b isn't a maze in a
+// version of the format it knows.
+func (m *Maze) UnmarshalBinary(b []byte) error {
+	invalid := func(format string, a ...interface{}) error {
+		return &FormatError{&BaseError{"Maze binary invalid: " + fmt.Sprintf(format, a...), nil}}
+	}
+	if !bytes.HasPrefix(b, []byte(mazeBinaryMagic)) || len(b) < len(mazeBinaryMagic)+1 {
+		return invalid("it doesn't start with %q and a version", mazeBinaryMagic)
+	}
+	if v := b[len(mazeBinaryMagic)]; v != mazeBinaryVersion {
+		return invalid("version %d is not %d, the only version this reads", v, mazeBinaryVersion)
+	}
+	r := bytes.NewReader(b[len(mazeBinaryMagic)+1:])
+	var header [6]int
+	for i := range header {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return invalid("the header is cut short")
+		}
+		if n >= maxBinarySide {
+			return invalid("%d is too big for a side or coordinate", n)
+		}
+		header[i] = int(n)
+	}
+	seed, err := binary.ReadVarint(r)
+	if err != nil {
+		return invalid("the header is cut short")
+	}
+	var strs [2]string
+	for i := range strs {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n > uint64(r.Len()) {
+			return invalid("the header is cut short")
+		}
+		s := make([]byte, n)
+		io.ReadFull(r, s)
+		strs[i] = string(s)
+	}
+	w, h := header[0], header[1]
+	if w < 1 || h < 1 {
+		return invalid("%dx%d is too small", w, h)
+	}
+	if want := (w*h + 7) / 8; r.Len() != want {
+		return invalid("%d bytes of locations for a %dx%d maze, which takes %d", r.Len(), w, h, want)
+	}
+	nm := NewMaze(w, h)
+	nm.algo, nm.seed = strs[0], seed
+	nm.perfect = creators[nm.algo].perfect
+	if strs[1] != "" {
+		if nm.policy, err = ParseGrowthPolicy(strs[1]); err != nil {
+			return invalid("%s", err)
+		}
+	}
+	bits := b[len(b)-r.Len():]
+	for i := range nm.grid.g {
+		nm.grid.g[i].Passable = bits[i/8]&(1<<(i%8)) != 0
+	}
+	start, finish := Coord{header[2], header[3]}, Coord{header[4], header[5]}
+	if start == finish {
+		return invalid("the start and finish are both %s", &start)
+	}
+	for _, end := range []struct {
+		name string
+		c    Coord
+		flag uint
+	}{{"start", start, Start}, {"finish", finish, Finish}} {
+		if !nm.grid.Within(end.c) {
+			return invalid("%s is off the %dx%d grid", &end.c, w, h)
+		}
+		l := &nm.grid.g[nm.grid.Idx(end.c)]
+		if !l.Passable {
+			return invalid("the %s %s is a wall", end.name, &end.c)
+		}
+		l.Special |= end.flag
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.grid, m.x, m.y = nm.grid, nm.x, nm.y
+	m.algo, m.policy, m.seed, m.perfect = nm.algo, nm.policy, nm.seed, nm.perfect
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// mazeJSONVersion is the version of the JSON schema written by MarshalJSON.
+// UnmarshalJSON reads only this version; change it whenever the schema
+// changes in a way older readers would get wrong.
+const mazeJSONVersion = 1
+
+// FormatError reports that a maze couldn't be read from its serialized form
+type FormatError struct {
+	*BaseError
+}
+
+type jsonCoord struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// mazeJSON is the JSON schema for a maze.  Rows has a string for each row,
+// from the top, with a character for each location, from the left: '#' for
+// a wall and '.' for a passage.  Marks are the flags other than start and
+// finish that creators leave on locations, and Costs the locations whose
+// step cost isn't the default.
+type mazeJSON struct {
+	Version   int         `json:"version"`
+	Width     int         `json:"width"`
+	Height    int         `json:"height"`
+	Algorithm string      `json:"algorithm,omitempty"`
+	Policy    string      `json:"policy,omitempty"`
+	Seed      int64       `json:"seed,string,omitempty"`
+	Start     *jsonCoord  `json:"start"`
+	Finish    *jsonCoord  `json:"finish"`
+	Rows      []string    `json:"rows"`
+	Marks     []jsonMark  `json:"marks,omitempty"`
+	Costs     []jsonCost  `json:"costs,omitempty"`
+	Solution  []jsonCoord `json:"solution,omitempty"`
+}
+
+type jsonMark struct {
+	jsonCoord
+	Flags []string `json:"flags"`
+}
+
+type jsonCost struct {
+	jsonCoord
+	Cost int `json:"cost"`
+}
+
+// markNames names the flags of Loc.Special that go in marks
+var markNames = []struct {
+	flag uint
+	name string
+}{
+	{Reverse, "reverse"},
+	{MaxPasses, "max_passes"},
+	{CreateEnd, "create_end"},
+}
+
+const (
+	jsonWall    = '#'
+	jsonPassage = '.'
+)
+
+// newMazeJSON describes m in the JSON schema, with solution if it's set
+func newMazeJSON(m *Maze, solution []Coord) *mazeJSON {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	mj := &mazeJSON{
+		Version:   mazeJSONVersion,
+		Width:     m.x,
+		Height:    m.y,
+		Algorithm: m.algo,
+		Seed:      m.seed,
+		Rows:      make([]string, m.y),
+	}
+	if m.policy.total() > 0 {
+		mj.Policy = m.policy.String()
+	}
+	var row strings.Builder
+	for i := 0; i < m.grid.Len(); i++ {
+		l := m.grid.AtIdx(i)
+		c := jsonCoord{l.X, l.Y}
+		if l.Passable {
+			row.WriteByte(jsonPassage)
+		} else {
+			row.WriteByte(jsonWall)
+		}
+		if l.X == m.x-1 {
+			mj.Rows[l.Y] = row.String()
+			row.Reset()
+		}
+		if l.Special&Start != 0 {
+			mj.Start = &jsonCoord{l.X, l.Y}
+		}
+		if l.Special&Finish != 0 {
+			mj.Finish = &jsonCoord{l.X, l.Y}
+		}
+		var flags []string
+		for _, mn := range markNames {
+			if l.Special&mn.flag != 0 {
+				flags = append(flags, mn.name)
+			}
+		}
+		if len(flags) > 0 {
+			mj.Marks = append(mj.Marks, jsonMark{c, flags})
+		}
+		if l.Cost > 1 {
+			mj.Costs = append(mj.Costs, jsonCost{c, l.Cost})
+		}
+	}
+	for _, c := range solution {
+		mj.Solution = append(mj.Solution, jsonCoord{c.X, c.Y})
+	}
+	return mj
+}
+
+// JSONRenderer writes a maze in the JSON schema, with its solution if set,
+// for programs that want the maze itself rather than a picture of it
+type JSONRenderer struct {
+	dest     io.Writer
+	solution []Coord
+}
+
+func (jr *JSONRenderer) Draw(m *Maze) {
+	if err := json.NewEncoder(jr.dest).Encode(newMazeJSON(m, jr.solution)); err != nil {
+		log.Printf("Encoding maze JSON: %s", err)
+	}
+}
+
+// MarshalJSON writes m in the versioned JSON schema of mazeJSON
+func (m *Maze) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newMazeJSON(m, nil))
+}
+
+// UnmarshalJSON reads a maze written by MarshalJSON into m, replacing
+// whatever was there.  It returns a *FormatError if the JSON isn't a maze of
+// a version it knows.
+func (m *Maze) UnmarshalJSON(b []byte) error {
+	var mj mazeJSON
+	if err := json.Unmarshal(b, &mj); err != nil {
+		return &FormatError{&BaseError{fmt.Sprintf("Maze JSON invalid: %s", err), err}}
+	}
+	invalid := func(format string, a ...interface{}) error {
+		return &FormatError{&BaseError{"Maze JSON invalid: " + fmt.Sprintf(format, a...), nil}}
+	}
+	if mj.Version != mazeJSONVersion {
+		return invalid("version %d is not %d, the only version this reads", mj.Version, mazeJSONVersion)
+	}
+	if mj.Width < 1 || mj.Height < 1 {
+		return invalid("%dx%d is too small", mj.Width, mj.Height)
+	}
+	if len(mj.Rows) != mj.Height {
+		return invalid("%d rows for a height of %d", len(mj.Rows), mj.Height)
+	}
+	for y, row := range mj.Rows {
+		if len(row) != mj.Width {
+			return invalid("row %d is %d long for a width of %d", y, len(row), mj.Width)
+		}
+		if strings.Trim(row, string([]byte{jsonWall, jsonPassage})) != "" {
+			return invalid("row %d has characters other than %c and %c", y, jsonWall, jsonPassage)
+		}
+	}
+	nm := NewMaze(mj.Width, mj.Height)
+	nm.algo, nm.seed = mj.Algorithm, mj.Seed
+	nm.perfect = creators[mj.Algorithm].perfect
+	if mj.Policy != "" {
+		p, err := ParseGrowthPolicy(mj.Policy)
+		if err != nil {
+			return invalid("%s", err)
+		}
+		nm.policy = p
+	}
+	for i := range nm.grid.g {
+		c := nm.grid.CoordOf(i)
+		nm.grid.g[i].Passable = mj.Rows[c.Y][c.X] == jsonPassage
+	}
+	// everything else is for a location, which must be on the grid
+	at := func(jc jsonCoord) (*Loc, error) {
+		c := Coord{jc.X, jc.Y}
+		if !nm.grid.Within(c) {
+			return nil, invalid("%s is off the %dx%d grid", &c, mj.Width, mj.Height)
+		}
+		return &nm.grid.g[nm.grid.Idx(c)], nil
+	}
+	for _, end := range []struct {
+		name string
+		c    *jsonCoord
+		flag uint
+	}{{"start", mj.Start, Start}, {"finish", mj.Finish, Finish}} {
+		if end.c == nil {
+			return invalid("there's no %s", end.name)
+		}
+		l, err := at(*end.c)
+		if err != nil {
+			return err
+		}
+		if !l.Passable {
+			return invalid("the %s %s is a wall", end.name, &l.Coord)
+		}
+		if l.Special&Start != 0 {
+			return invalid("the start and finish are both %s", &l.Coord)
+		}
+		l.Special |= end.flag
+	}
+	for _, mark := range mj.Marks {
+		l, err := at(mark.jsonCoord)
+		if err != nil {
+			return err
+		}
+	flags:
+		for _, f := range mark.Flags {
+			for _, mn := range markNames {
+				if f == mn.name {
+					l.Special |= mn.flag
+					continue flags
+				}
+			}
+			return invalid("no such mark %q", f)
+		}
+	}
+	for _, cost := range mj.Costs {
+		l, err := at(cost.jsonCoord)
+		if err != nil {
+			return err
+		}
+		l.Cost = cost.Cost
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.grid, m.x, m.y = nm.grid, nm.x, nm.y
+	m.algo, m.policy, m.seed, m.perfect = nm.algo, nm.policy, nm.seed, nm.perfect
+	return nil
+}
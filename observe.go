@@ -0,0 +1,54 @@
+package main
+
+import "log"
+
+// Event is something that happened while a maze was being generated.  Its
+// Step says what and where; candidate events also say where the creator was
+// looking from, what it decided, and why.
+type Event struct {
+	Step
+	From   Coord  // for StepCandidate, the location the candidate neighbors
+	Accept bool   // for StepCandidate, whether the candidate was accepted
+	Reason string // why it happened, for people reading along
+}
+
+// Observer is told about every Event of a maze's generation, in order.
+// Creators report them through the Grid they are filling, so give the Grid
+// its Observer with SetObserver before calling Fill.
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc lets an ordinary function be an Observer
+type ObserverFunc func(Event)
+
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// Timeline is an Observer that keeps the steps that change the maze or show
+// where the creator went, which is what SVGRenderer animates
+type Timeline []Step
+
+func (t *Timeline) Observe(e Event) {
+	switch e.Kind {
+	case StepCarve, StepWall, StepJump, StepReverse:
+		*t = append(*t, e.Step)
+	}
+}
+
+// LogObserver logs every event
+type LogObserver struct{}
+
+func (LogObserver) Observe(e Event) {
+	switch e.Kind {
+	case StepCandidate:
+		log.Printf("Cur %s: Filter result for %s: %t (%s)", &e.From, &e.Coord, e.Accept, e.Reason)
+	default:
+		if e.Reason != "" {
+			log.Printf("%s at %s: %s", e.Kind, &e.Coord, e.Reason)
+		} else {
+			log.Printf("%s at %s", e.Kind, &e.Coord)
+		}
+	}
+}
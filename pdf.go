@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pdfDoc is just enough of a PDF writer for worksheets: pages of filled
+// rectangles, lines and Helvetica text.  Coordinates are in points, 72 to the
+// inch, from the top left of the page like everywhere else in this program;
+// they are flipped to PDF's bottom left origin as they are written.
+type pdfDoc struct {
+	width, height float64 // of every page
+	pages         []*pdfPage
+}
+
+// paper sizes in points, portrait
+var paperSizes = map[string][2]float64{
+	"a4":     {595.28, 841.89},
+	"letter": {612, 792},
+}
+
+// pdfPage is the content stream of one page
+type pdfPage struct {
+	doc     *pdfDoc
+	content bytes.Buffer
+}
+
+func (d *pdfDoc) NewPage() *pdfPage {
+	p := &pdfPage{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// pdfNum formats n without needless digits
+func pdfNum(n float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.3f", n), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Fill sets the color of what's filled from here on, each part from 0 to 1
+func (p *pdfPage) Fill(r, g, b float64) {
+	fmt.Fprintf(&p.content, "%s %s %s rg\n", pdfNum(r), pdfNum(g), pdfNum(b))
+}
+
+// Stroke sets the color and width of lines from here on
+func (p *pdfPage) Stroke(r, g, b, width float64) {
+	fmt.Fprintf(&p.content, "%s %s %s RG %s w 1 J 1 j\n", pdfNum(r), pdfNum(g), pdfNum(b), pdfNum(width))
+}
+
+// Rect fills a rectangle w wide and h high from x, y
+func (p *pdfPage) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n",
+		pdfNum(x), pdfNum(p.doc.height-y-h), pdfNum(w), pdfNum(h))
+}
+
+// Polyline draws a line through the points xs[i], ys[i]
+func (p *pdfPage) Polyline(xs, ys []float64) {
+	for i := range xs {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(&p.content, "%s %s %s\n", pdfNum(xs[i]), pdfNum(p.doc.height-ys[i]), op)
+	}
+	p.content.WriteString("S\n")
+}
+
+// Text writes s in Helvetica of the given size with its baseline at y.
+// Characters Helvetica's encoding doesn't have come out as "?".
+func (p *pdfPage) Text(x, y, size float64, s string) {
+	var esc strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			esc.WriteByte('\\')
+			esc.WriteRune(r)
+		case r < ' ' || r > 0xff || (r >= 0x7f && r < 0xa0):
+			esc.WriteByte('?')
+		case r > 0x7f:
+			fmt.Fprintf(&esc, "\\%03o", r)
+		default:
+			esc.WriteRune(r)
+		}
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n",
+		pdfNum(size), pdfNum(x), pdfNum(p.doc.height-y), esc.String())
+}
+
+// WriteTo writes the document out as a PDF
+func (d *pdfDoc) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	var offsets []int
+	// objects are numbered from 1 in the order they're written: the catalog,
+	// the page tree, the font, then each page followed by its content
+	obj := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), pdfNum(d.width), pdfNum(d.height)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i))
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(p.content.Bytes())
+		zw.Close()
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.WriteTo(w)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+)
+
+// PNGRenderer draws a maze as a PNG, laid out like SVGRenderer's: scale pixels
+// for each location, a border of one location around the maze, and passages
+// 4/5 of a location wide, but at least a pixel.  The start is marked green and
+// the finish blue, since there is no font to label them with.
+type PNGRenderer struct {
+	dest     io.Writer
+	scale    int     // size of each location
+	solution []Coord // drawn over the maze, if set
+}
+
+// maxPNGPixels keeps a big maze at a big scale from taking all our memory;
+// at one byte a pixel that's 16MB
+const maxPNGPixels = 1 << 24
+
+// pngPalette has the only colors a PNGRenderer draws with, so the image
+// takes a byte a pixel
+var pngPalette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xff}, // black
+	color.RGBA{0xff, 0xff, 0xff, 0xff}, // white
+	color.RGBA{0x00, 0x80, 0x00, 0xff}, // green
+	color.RGBA{0x46, 0x82, 0xb4, 0xff}, // steelblue
+	color.RGBA{0xe6, 0x5a, 0x76, 0xff}, // crimson at the SVG's 0.7 opacity over white
+}
+
+// indices into pngPalette
+const (
+	pngWall uint8 = iota
+	pngOpen
+	pngStart
+	pngFinish
+	pngSolution
+)
+
+func (pr *PNGRenderer) Draw(m *Maze) {
+	// a new paletted image is all index 0, pngWall
+	img := image.NewPaletted(image.Rect(0, 0, (m.x+2)*pr.scale, (m.y+2)*pr.scale), pngPalette)
+	wide := 4 * pr.scale / 5
+	if wide < 1 {
+		wide = 1 // at scale 1, a passage is the whole location
+	}
+	var ends []Loc
+	i, _ := m.Iter()
+	for loc := range i {
+		if !loc.Passable {
+			continue
+		}
+		pr.band(img, loc.Coord, loc.Coord, wide, pngOpen)
+		for _, n := range []Coord{{loc.X + 1, loc.Y}, {loc.X, loc.Y + 1}} {
+			if l, err := m.At(n.X, n.Y); err == nil && l.Passable {
+				pr.band(img, loc.Coord, n, wide, pngOpen)
+			}
+		}
+		if loc.Special&(Start|Finish) != 0 {
+			ends = append(ends, loc)
+		}
+	}
+	for i := 1; i < len(pr.solution); i++ {
+		pr.band(img, pr.solution[i-1], pr.solution[i], pr.scale/3+1, pngSolution)
+	}
+	for _, l := range ends {
+		c := pngFinish
+		if l.Special&Start != 0 {
+			c = pngStart
+		}
+		pr.band(img, l.Coord, l.Coord, wide/2+1, c)
+	}
+	if err := png.Encode(pr.dest, img); err != nil {
+		log.Printf("Encoding PNG: %s", err)
+	}
+}
+
+// center is the pixel at the middle of location c
+func (pr *PNGRenderer) center(c Coord) image.Point {
+	return image.Pt((c.X+1)*pr.scale+pr.scale/2, (c.Y+1)*pr.scale+pr.scale/2)
+}
+
+// band fills a stripe wide pixels across from the middle of a to the middle of
+// b, which must be in the same row or column, with the color at index ci
+func (pr *PNGRenderer) band(img *image.Paletted, a, b Coord, wide int, ci uint8) {
+	r := image.Rectangle{pr.center(a), pr.center(b)}.Canon()
+	r.Min = r.Min.Sub(image.Pt(wide/2, wide/2))
+	r.Max = r.Max.Add(image.Pt(wide-wide/2, wide-wide/2))
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)]
+		for x := range row {
+			row[x] = ci
+		}
+	}
+}
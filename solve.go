@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Solver finds a path through a filled grid from the location flagged Start
+// to the one flagged Finish, moving orthogonally between passable locations.
+// The path includes both ends.
+type Solver interface {
+	Solve(grid *Grid) ([]Coord, error)
+}
+
+// TracingSolver is a Solver that records every step it takes, so the way it
+// explores a maze can be shown
+type TracingSolver interface {
+	Solver
+	// Trace returns the steps taken by the last call to Solve, whether or not
+	// it found a solution
+	Trace() []Step
+}
+
+// solverEntry describes a Solver and builds a fresh one
+type solverEntry struct {
+	description string
+	create      func() Solver
+}
+
+// solvers maps the name of each Solver, as the API takes it, to its entry
+var solvers = map[string]solverEntry{
+	"bfs": {
+		"Breadth first search; a shortest path",
+		func() Solver { return &BFSSolver{} },
+	},
+	"astar": {
+		"A* search; the cheapest path when locations have a step cost",
+		func() Solver { return &AStarSolver{} },
+	},
+	"deadend": {
+		"Dead end filling; fills dead ends until only the solution is left",
+		func() Solver { return &DeadEndSolver{} },
+	},
+	"left": {
+		"Left hand wall follower; can go in circles where the maze has loops",
+		func() Solver { return &WallFollowerSolver{hand: LeftHand} },
+	},
+	"right": {
+		"Right hand wall follower; can go in circles where the maze has loops",
+		func() Solver { return &WallFollowerSolver{hand: RightHand} },
+	},
+}
+
+const defaultSolver = "bfs"
+
+// solverNames returns the names of all the solvers, sorted
+func solverNames() []string {
+	names := make([]string, 0, len(solvers))
+	for n := range solvers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SolveError reports that a grid has no solution, or nothing to solve
+type SolveError struct {
+	*BaseError
+}
+
+// findEnds returns the locations flagged Start and Finish
+func findEnds(grid *Grid) (start, finish Coord, err error) {
+	var found uint
+	for i := 0; i < grid.Len(); i++ {
+		l := grid.AtIdx(i)
+		if l.Special&Start != 0 {
+			start = l.Coord
+			found |= Start
+		}
+		if l.Special&Finish != 0 {
+			finish = l.Coord
+			found |= Finish
+		}
+	}
+	if found != Start|Finish {
+		err = &SolveError{&BaseError{
+			fmt.Sprintf("%s grid has no location flagged as the start and the finish", &grid.dims),
+			nil,
+		}}
+	}
+	return
+}
+
+// BFSSolver finds the shortest path with a breadth first search.  Its trace
+// is every location it explored, in order, so it shows the search frontier
+// spreading out from the start.
+type BFSSolver struct {
+	steps []Step
+}
+
+func (bs *BFSSolver) Trace() []Step {
+	return bs.steps
+}
+
+func (bs *BFSSolver) Solve(grid *Grid) ([]Coord, error) {
+	bs.steps = nil
+	start, finish, err := findEnds(grid)
+	if err != nil {
+		return nil, err
+	}
+	// prev[i] is the index we first reached i from, or -1 if we haven't yet
+	prev := make([]int, grid.Len())
+	for i := range prev {
+		prev[i] = -1
+	}
+	prev[grid.Idx(start)] = grid.Idx(start)
+	queue := []Coord{start}
+	for len(queue) > 0 && queue[0] != finish {
+		bs.steps = append(bs.steps, Step{queue[0], StepMove})
+		on, _ := grid.Neighbors(queue[0])
+		for _, n := range on {
+			if grid.At(n).Passable && prev[grid.Idx(n)] < 0 {
+				prev[grid.Idx(n)] = grid.Idx(queue[0])
+				queue = append(queue, n)
+			}
+		}
+		queue = queue[1:]
+	}
+	if len(queue) > 0 {
+		bs.steps = append(bs.steps, Step{finish, StepMove})
+	}
+	if prev[grid.Idx(finish)] < 0 {
+		return nil, &SolveError{&BaseError{
+			fmt.Sprintf("There is no path from %s to %s", &start, &finish),
+			nil,
+		}}
+	}
+	return tracePath(grid, prev, finish), nil
+}
+
+// tracePath follows prev back from finish to the location that is its own
+// previous, and returns that path in forward order
+func tracePath(grid *Grid, prev []int, finish Coord) []Coord {
+	var path []Coord
+	for i := grid.Idx(finish); ; i = prev[i] {
+		path = append(path, grid.CoordOf(i))
+		if prev[i] == i {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
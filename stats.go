@@ -0,0 +1,127 @@
+package main
+
+// Stats are measurements of a finished maze, for comparing creators and
+// picking seeds.  Lengths are in locations.
+type Stats struct {
+	// SolutionLength is the number of locations on the shortest path from the
+	// start to the finish, both included
+	SolutionLength int `json:"solution_length"`
+	// Turns is how many times the shortest path changes direction
+	Turns int `json:"turns"`
+	// DeadEnds is the number of passable locations with only one way out,
+	// other than the start and finish
+	DeadEnds int `json:"dead_ends"`
+	// Junctions is the number of passable locations with three or more ways
+	// out
+	Junctions int `json:"junctions"`
+	// BranchingFactor is the average number of ways on, other than the way
+	// in, at each location of the shortest path before the finish.  A maze
+	// whose solution is one long corridor scores 1.
+	BranchingFactor float64 `json:"branching_factor"`
+	// LongestCorridor is the longest run of passable locations with exactly
+	// two ways out, so with no choices to make along it
+	LongestCorridor int `json:"longest_corridor"`
+	// Carved is the fraction of all locations that are passable
+	Carved float64 `json:"carved"`
+	// River is how much of the maze off the solution belongs to long dead end
+	// branches rather than short stubs: 1 less the dead ends per location off
+	// the solution.  Mazes that flow, with few long dead ends, score near 1.
+	River float64 `json:"river"`
+	// Difficulty scores how hard the maze is to solve for its size: the
+	// solution length plus two for every wrong way off it, over the length of
+	// the straightest possible path from corner to corner.  Most mazes score
+	// between 1 and 4.
+	Difficulty float64 `json:"difficulty"`
+}
+
+// exits counts the passable locations orthogonally adjacent to c
+func exits(grid *Grid, c Coord) (n int) {
+	on, _ := grid.Neighbors(c)
+	for _, o := range on {
+		if grid.At(o).Passable {
+			n++
+		}
+	}
+	return
+}
+
+// Measure computes the Stats of a filled maze.  It returns a *SolveError if
+// the maze has no path from its start to its finish.
+func Measure(m *Maze) (*Stats, error) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	grid := &m.grid
+	path, err := (&BFSSolver{}).Solve(grid)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stats{SolutionLength: len(path)}
+	onPath := make(map[Coord]bool, len(path))
+	for i, c := range path {
+		onPath[c] = true
+		if i > 1 && path[i-2].Diff(path[i-1]) != path[i-1].Diff(c) {
+			s.Turns++
+		}
+	}
+	var choices int
+	if len(path) > 1 {
+		for i, c := range path[:len(path)-1] {
+			choices += exits(grid, c)
+			if i > 0 {
+				choices-- // the way in
+			}
+		}
+		s.BranchingFactor = float64(choices) / float64(len(path)-1)
+	}
+	wrongWays := choices - (len(path) - 1)
+	s.Difficulty = float64(len(path)+2*wrongWays) / float64(grid.dims.X+grid.dims.Y-1)
+	start, finish := path[0], path[len(path)-1]
+	var passable, offPath int
+	// corridor[i] is set once location i is counted in a corridor
+	corridor := make([]bool, grid.Len())
+	for i := 0; i < grid.Len(); i++ {
+		l := grid.AtIdx(i)
+		if !l.Passable {
+			continue
+		}
+		passable++
+		if !onPath[l.Coord] {
+			offPath++
+		}
+		switch n := exits(grid, l.Coord); {
+		case n <= 1 && l.Coord != start && l.Coord != finish:
+			s.DeadEnds++
+		case n >= 3:
+			s.Junctions++
+		case n == 2 && !corridor[i]:
+			if length := corridorLength(grid, l.Coord, corridor); length > s.LongestCorridor {
+				s.LongestCorridor = length
+			}
+		}
+	}
+	s.Carved = float64(passable) / float64(grid.Len())
+	if offPath > 0 {
+		s.River = 1 - float64(s.DeadEnds)/float64(offPath)
+	}
+	return s, nil
+}
+
+// corridorLength counts the locations with two ways out that are joined to c
+// through others like it, marking each in seen
+func corridorLength(grid *Grid, c Coord, seen []bool) (length int) {
+	seen[grid.Idx(c)] = true
+	queue := []Coord{c}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		length++
+		on, _ := grid.Neighbors(cur)
+		for _, n := range on {
+			if i := grid.Idx(n); !seen[i] && grid.At(n).Passable && exits(grid, n) == 2 {
+				seen[i] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return
+}
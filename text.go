@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TextRenderer draws a maze as plain text with no escape codes, to paste into
+// emails, code comments and tickets.  Each location takes two characters, so
+// the maze comes out about as wide as it is tall.  A wall is drawn as a line
+// joining the walls next to it, which makes the maze look like the usual
+// +--+ drawing, and the passages are blank.
+type TextRenderer struct {
+	dest     io.Writer
+	charset  *textCharset
+	solution []Coord // marked on the maze, if set
+}
+
+// textCharset is what a TextRenderer draws with
+type textCharset struct {
+	// walls is the character for a wall, indexed by which of its neighbors
+	// are walls too: 1 above, 2 right, 4 below, 8 left
+	walls      [16]string
+	horizontal string // after a wall that joins the one to its right
+	start      string
+	finish     string
+	path       string // a location on the solution
+}
+
+var asciiCharset = &textCharset{
+	walls: [16]string{
+		"+", "|", "-", "+", "|", "|", "+", "+",
+		"-", "+", "-", "+", "+", "+", "+", "+",
+	},
+	horizontal: "-",
+	start:      "S",
+	finish:     "F",
+	path:       ".",
+}
+
+var boxCharset = &textCharset{
+	walls: [16]string{
+		"■", "│", "─", "└", "│", "│", "┌", "├",
+		"─", "┘", "─", "┴", "┐", "┤", "┬", "┼",
+	},
+	horizontal: "─",
+	start:      "S",
+	finish:     "F",
+	path:       "·",
+}
+
+// textCharsets are the charsets by the name the API takes them as a format
+var textCharsets = map[string]*textCharset{
+	"ascii":   asciiCharset,
+	"unicode": boxCharset,
+}
+
+func (tr *TextRenderer) Draw(m *Maze) {
+	onPath := make(map[Coord]bool, len(tr.solution))
+	for _, c := range tr.solution {
+		onPath[c] = true
+	}
+	wall := func(x, y int) bool {
+		if x < -1 || x > m.x || y < -1 || y > m.y {
+			return false // beyond the border
+		}
+		// and just off the grid is the border
+		l, err := m.At(x, y)
+		return err != nil || !l.Passable
+	}
+	var b strings.Builder
+	for y := -1; y <= m.y; y++ {
+		for x := -1; x <= m.x; x++ {
+			if wall(x, y) {
+				var joins int
+				for i, n := range []Coord{{x, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y}} {
+					if wall(n.X, n.Y) {
+						joins |= 1 << i
+					}
+				}
+				b.WriteString(tr.charset.walls[joins])
+				if x < m.x {
+					if wall(x+1, y) {
+						b.WriteString(tr.charset.horizontal)
+					} else {
+						b.WriteString(" ")
+					}
+				}
+				continue
+			}
+			l, _ := m.At(x, y)
+			switch {
+			case l.Special&Start != 0:
+				b.WriteString(tr.charset.start)
+			case l.Special&Finish != 0:
+				b.WriteString(tr.charset.finish)
+			case onPath[l.Coord]:
+				b.WriteString(tr.charset.path)
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(" ")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprint(tr.dest, b.String())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError reports the ways a maze breaks the invariants Validate
+// checks, one problem each
+type ValidationError struct {
+	*BaseError
+	Problems []string
+}
+
+func newValidationError(problems []string) *ValidationError {
+	return &ValidationError{
+		&BaseError{"Invalid maze: " + strings.Join(problems, "; "), nil},
+		problems,
+	}
+}
+
+// Validate checks the invariants every creator should keep: the start and
+// finish are passable and connected, every passable location can be reached
+// from the start, and no 2x2 block of locations is all passable.  If the maze
+// was made by a creator of perfect mazes, it also checks there are no loops,
+// so there is exactly one path between any two locations.  It returns a
+// *ValidationError listing everything wrong, or nil.
+func Validate(m *Maze) error {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	grid := &m.grid
+	var problems []string
+	start, finish, err := findEnds(grid)
+	if err != nil {
+		return newValidationError([]string{err.Error()})
+	}
+	for _, c := range []Coord{start, finish} {
+		if !grid.At(c).Passable {
+			problems = append(problems, fmt.Sprintf("%s is a wall", &c))
+		}
+	}
+	// reached[i] is set once location i is found from the start
+	reached := make([]bool, grid.Len())
+	reached[grid.Idx(start)] = true
+	queue := []Coord{start}
+	var nodes, edges int
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		nodes++
+		on, _ := grid.Neighbors(cur)
+		for _, n := range on {
+			if !grid.At(n).Passable {
+				continue
+			}
+			if grid.Idx(n) > grid.Idx(cur) {
+				edges++
+			}
+			if !reached[grid.Idx(n)] {
+				reached[grid.Idx(n)] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	if !reached[grid.Idx(finish)] {
+		problems = append(problems, fmt.Sprintf("no path from %s to %s", &start, &finish))
+	}
+	var unreached int
+	for i := 0; i < grid.Len(); i++ {
+		l := grid.AtIdx(i)
+		if l.Passable && !reached[i] {
+			unreached++
+		}
+		if l.X+1 < grid.dims.X && l.Y+1 < grid.dims.Y && l.Passable &&
+			grid.At(Coord{l.X + 1, l.Y}).Passable &&
+			grid.At(Coord{l.X, l.Y + 1}).Passable &&
+			grid.At(Coord{l.X + 1, l.Y + 1}).Passable {
+			problems = append(problems, fmt.Sprintf("2x2 open block at %s", &l.Coord))
+		}
+	}
+	if unreached > 0 {
+		problems = append(problems, fmt.Sprintf("%d passable locations can't be reached from %s", unreached, &start))
+	}
+	if m.perfect && edges > nodes-1 {
+		problems = append(problems, fmt.Sprintf("%d loops in a perfect maze", edges-(nodes-1)))
+	}
+	if len(problems) > 0 {
+		return newValidationError(problems)
+	}
+	return nil
+}
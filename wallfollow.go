@@ -0,0 +1,94 @@
+package main
+
+import "fmt"
+
+// Hand is the hand a WallFollowerSolver keeps on the wall
+type Hand int
+
+const (
+	LeftHand Hand = iota
+	RightHand
+)
+
+func (h Hand) String() string {
+	if h == RightHand {
+		return "right"
+	}
+	return "left"
+}
+
+// headings in clockwise order, so turning right is the next one
+var headings = []Trans{Upper, Right, Lower, Left}
+
+// WallFollowerSolver walks the maze keeping one hand on the wall: at each
+// location it turns toward that hand if it can, else goes straight, else
+// turns away, else turns back.  In a perfect maze that always finds the
+// finish, but where there are loops it can end up circling a section of wall
+// that the finish isn't on forever; when it finds itself back where it has
+// already been, facing the same way, it gives up.
+type WallFollowerSolver struct {
+	hand  Hand
+	steps []Step
+}
+
+func (ws *WallFollowerSolver) Trace() []Step {
+	return ws.steps
+}
+
+// turns returns the order headings are tried in from heading h
+func (ws *WallFollowerSolver) turns(h int) []int {
+	toward, away := (h+3)%4, (h+1)%4
+	if ws.hand == RightHand {
+		toward, away = away, toward
+	}
+	return []int{toward, h, away, (h + 2) % 4}
+}
+
+func (ws *WallFollowerSolver) Solve(grid *Grid) ([]Coord, error) {
+	ws.steps = nil
+	start, finish, err := findEnds(grid)
+	if err != nil {
+		return nil, err
+	}
+	// been[4*i+h] is set once we've left location i heading h
+	been := make([]bool, 4*grid.Len())
+	cur, h := start, 1
+	// path is the walk with its loops erased, at[i] where i is on it
+	path := []Coord{start}
+	at := map[Coord]int{start: 0}
+	for cur != finish {
+		moved := false
+		for _, t := range ws.turns(h) {
+			next := headings[t].Translate(cur)
+			if grid.Within(next) && grid.At(next).Passable {
+				if been[4*grid.Idx(cur)+t] {
+					return nil, &SolveError{&BaseError{
+						fmt.Sprintf("Following the %s hand wall from %s goes in circles without reaching %s",
+							ws.hand, &start, &finish),
+						nil,
+					}}
+				}
+				been[4*grid.Idx(cur)+t] = true
+				cur, h, moved = next, t, true
+				break
+			}
+		}
+		if !moved {
+			return nil, &SolveError{&BaseError{
+				fmt.Sprintf("The start %s is walled in", &start),
+				nil,
+			}}
+		}
+		ws.steps = append(ws.steps, Step{cur, StepMove})
+		if i, ok := at[cur]; ok {
+			for _, c := range path[i+1:] {
+				delete(at, c)
+			}
+			path = path[:i+1]
+		} else {
+			at[cur] = len(path)
+			path = append(path, cur)
+		}
+	}
+	return path, nil
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"regexp"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
@@ -56,26 +61,249 @@ func getQueryInt64(r *http.Request, key string) (int64, error) {
 type MazeRequest struct {
 	x, y, scale int
 	seed        int64
+	algo        string          // key into creators; empty means defaultCreator
+	policy      GrowthPolicy    // for growingtree
+	solution    bool            // draw the solution over the maze
+	solver      string          // key into solvers; empty means defaultSolver
+	animate     string          // "solve" animates the solver exploring the maze, "generate" the creator making it
+	stats       bool            // respond with the maze's Stats instead of drawing it
+	difficulty  *DifficultyBand // if not nil, search from seed for a maze in this band
+	format      string          // "svg", "png", "json", or a key of textCharsets; empty means svg
 }
 
 func (mr *MazeRequest) Path() string {
-	return fmt.Sprintf("/%dx%d/%d?s=%d", mr.x, mr.y, mr.seed,mr.scale)
+	p := fmt.Sprintf("/api/maze/%dx%d/%d", mr.x, mr.y, mr.seed)
+	if mr.stats {
+		p += "/stats"
+	}
+	return p + fmt.Sprintf("?s=%d", mr.scale) + mr.options()
 }
 
-func (mr *MazeRequest) RenderSVGMaze(w http.ResponseWriter) {
-	//log.Printf("%#v Rendering", *mr)
+// options is the query string, after the scale, for everything requested
+// that isn't the default
+func (mr *MazeRequest) options() (p string) {
+	if mr.algo != "" {
+		p += "&algo=" + url.QueryEscape(mr.algo)
+	}
+	if mr.policy.total() > 0 {
+		p += "&policy=" + url.QueryEscape(mr.policy.String())
+	}
+	if mr.solution {
+		p += "&solution=1"
+	}
+	if mr.solver != "" {
+		p += "&solver=" + url.QueryEscape(mr.solver)
+	}
+	if mr.animate != "" {
+		p += "&animate=" + url.QueryEscape(mr.animate)
+	}
+	if mr.difficulty != nil {
+		p += "&difficulty=" + url.QueryEscape(mr.difficulty.String())
+	}
+	if mr.format != "" {
+		p += "&format=" + url.QueryEscape(mr.format)
+	}
+	return p
+}
+
+// algorithm is the name of the requested algorithm
+func (mr *MazeRequest) algorithm() string {
+	if mr.algo == "" {
+		return defaultCreator
+	}
+	return mr.algo
+}
+
+// Creator returns the seeded MazeCreator for the requested algorithm
+func (mr *MazeRequest) Creator() MazeCreator {
+	return creators[mr.algorithm()].create(mr)
+}
+
+// generationBudget is how long a maze may take to generate before the request
+// is abandoned with a 503.  GENERATION_BUDGET overrides it.
+var generationBudget = 5 * time.Second
+
+// Generate creates the requested maze, from the upper left corner to the
+// lower right, giving up when ctx is done
+func (mr *MazeRequest) Generate(ctx context.Context) (*Maze, error) {
+	return mr.GenerateObserved(ctx, nil)
+}
+
+// Solver returns a Solver for the requested solving algorithm
+func (mr *MazeRequest) Solver() Solver {
+	solver := mr.solver
+	if solver == "" {
+		solver = defaultSolver
+	}
+	return solvers[solver].create()
+}
+
+// GenerateObserved is Generate, telling o, if not nil, about every event
+func (mr *MazeRequest) GenerateObserved(ctx context.Context, o Observer) (*Maze, error) {
 	m := NewMaze(mr.x, mr.y)
-	wc := &WalkingCreator{seed: mr.seed}
-	wc.Fill(&m.grid, Coord{0, 0}, Coord{m.x - 1, m.y - 1})
+	m.perfect = creators[mr.algorithm()].perfect
+	m.algo, m.policy, m.seed = mr.algorithm(), mr.policy, mr.seed
+	if o != nil {
+		m.grid.SetObserver(o)
+	}
+	if err := mr.Creator().Fill(ctx, &m.grid, Coord{0, 0}, Coord{m.x - 1, m.y - 1}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeListing responds with a JSON list of names and their descriptions,
+// marking which is the default
+func writeListing(w http.ResponseWriter, names []string, def string, describe func(string) string) {
+	type listing struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Default     bool   `json:"default"`
+	}
+	var l []listing
+	for _, n := range names {
+		l = append(l, listing{n, describe(n), n == def})
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(l)
+}
+
+// writeJSONError responds with status and a body of {"error": "..."}
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+// writeGenerationError responds to a failed Generate: with a 503 and a hint
+// to retry if it ran out of time or the client went away, or a 500 otherwise
+func (mr *MazeRequest) writeGenerationError(w http.ResponseWriter, err error) {
+	log.Printf("Generating %s: %s", mr.Path(), err)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		retry := int(generationBudget.Seconds())
+		if retry < 1 {
+			retry = 1
+		}
+		w.Header().Add("Retry-After", strconv.Itoa(retry))
+		writeJSONError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSONError(w, http.StatusInternalServerError, err)
+}
+
+func (mr *MazeRequest) RenderSVGMaze(ctx context.Context, w http.ResponseWriter) {
+	//log.Printf("%#v Rendering", *mr)
+	var timeline Timeline
+	var o Observer
+	if mr.animate == "generate" {
+		o = &timeline
+	}
+	m, err := mr.GenerateObserved(ctx, o)
+	if err != nil {
+		mr.writeGenerationError(w, err)
+		return
+	}
 	svgd := SVGRenderer{
 		dest:  w,
 		scale: mr.scale,
 	}
+	if mr.animate == "generate" {
+		svgd.timeline = timeline
+	} else if mr.animate == "solve" {
+		// animate the requested solver if it has a trace to show, BFS if not
+		ts, ok := mr.Solver().(TracingSolver)
+		if !ok {
+			ts = &BFSSolver{}
+		}
+		svgd.solution, err = ts.Solve(&m.grid)
+		svgd.trace = ts.Trace()
+		if err != nil {
+			// the trace of a failure is still worth watching
+			log.Printf("Solving %s: %s", mr.Path(), err)
+		}
+	} else if mr.solution {
+		var ok bool
+		if svgd.solution, ok = mr.solve(w, m); !ok {
+			return
+		}
+	}
 	w.Header().Add("Content-Type", "image/svg+xml")
 	w.WriteHeader(http.StatusOK)
 	svgd.Draw(m)
 }
 
+// solve returns the requested solver's solution to m.  If there is none, it
+// responds with a 422 and returns false.
+func (mr *MazeRequest) solve(w http.ResponseWriter, m *Maze) ([]Coord, bool) {
+	path, err := mr.Solver().Solve(&m.grid)
+	if err != nil {
+		// not every solver can solve every maze, which is worth showing
+		log.Printf("Solving %s: %s", mr.Path(), err)
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+	return path, true
+}
+
+// RenderMaze responds with the requested maze, drawn by the Renderer that
+// renderer returns given the solution to draw, if one was asked for.  It
+// draws mazes that aren't animated.
+func (mr *MazeRequest) RenderMaze(ctx context.Context, w http.ResponseWriter, contentType string,
+	renderer func(solution []Coord) Renderer) {
+	m, err := mr.Generate(ctx)
+	if err != nil {
+		mr.writeGenerationError(w, err)
+		return
+	}
+	var solution []Coord
+	if mr.solution {
+		var ok bool
+		if solution, ok = mr.solve(w, m); !ok {
+			return
+		}
+	}
+	w.Header().Add("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	renderer(solution).Draw(m)
+}
+
+// RenderStats responds with the Stats of the requested maze as JSON
+func (mr *MazeRequest) RenderStats(ctx context.Context, w http.ResponseWriter) {
+	m, err := mr.Generate(ctx)
+	if err != nil {
+		mr.writeGenerationError(w, err)
+		return
+	}
+	s, err := Measure(m)
+	if err != nil {
+		log.Printf("Measuring %s: %s", mr.Path(), err)
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s)
+}
+
+// accepts says whether r's Accept header lists the media type mt by name,
+// rather than only through a wildcard like image/*
+func accepts(r *http.Request, mt string) bool {
+	for _, h := range r.Header.Values("Accept") {
+		for _, a := range strings.Split(h, ",") {
+			if i := strings.Index(a, ";"); i >= 0 {
+				a = a[:i]
+			}
+			if strings.TrimSpace(a) == mt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (mr *MazeRequest) SetFromStrings(x, y, scale, seed string) error {
 	var nmr MazeRequest = *mr
 	if ix, err := strconv.Atoi(x); err != nil {
@@ -122,11 +350,55 @@ func (mr *MazeRequest) Validate() error {
 			}}
 		}
 	}
+	if _, ok := creators[mr.algo]; mr.algo != "" && !ok {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("No such maze generation algorithm %q; available algorithms are %s",
+				mr.algo, strings.Join(creatorNames(), ", ")),
+			nil,
+		}}
+	}
+	if _, ok := solvers[mr.solver]; mr.solver != "" && !ok {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("No such maze solving algorithm %q; available solvers are %s",
+				mr.solver, strings.Join(solverNames(), ", ")),
+			nil,
+		}}
+	}
+	if mr.animate != "" && mr.animate != "solve" && mr.animate != "generate" {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("Can't animate %q; the animations are solve and generate", mr.animate),
+			nil,
+		}}
+	}
 	if mr.scale <= 0 {
 			return &ParamOutOfBoundsError{&BaseError{
-				fmt.Sprintf("Scale %s is out of bounds; it must be a positive number", mr.scale),
+				fmt.Sprintf("Scale %d is out of bounds; it must be a positive number", mr.scale),
+				nil,
+			}}
+	}
+	if _, text := textCharsets[mr.format]; (text || mr.format == "png" || mr.format == "json") && mr.animate != "" {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("Can't animate %s; animations are only drawn in SVG", mr.format),
+			nil,
+		}}
+	}
+	switch mr.format {
+	case "", "svg", "ascii", "unicode", "json":
+	case "png":
+		// checking each side first, so the area can't overflow
+		if mr.scale > maxPNGPixels || (mr.x+2)*mr.scale > maxPNGPixels || (mr.y+2)*mr.scale > maxPNGPixels ||
+			(mr.x+2)*mr.scale*(mr.y+2)*mr.scale > maxPNGPixels {
+			return &ParamOutOfBoundsError{&BaseError{
+				fmt.Sprintf("A %dx%d PNG at scale %d is too big; it can have at most %d pixels",
+					mr.x, mr.y, mr.scale, maxPNGPixels),
 				nil,
 			}}
+		}
+	default:
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("No such format %q; the formats are svg, png, ascii, unicode and json", mr.format),
+			nil,
+		}}
 	}
 	return nil
 }
@@ -149,7 +421,7 @@ func main() {
 
 func ServerMux() *http.ServeMux {
 	// generate a maze
-	var maze_path_re = regexp.MustCompile(`/api/maze/(?P<x>\d+)x(?P<y>\d+)/(?P<seed>\d+)$`)
+	var maze_path_re = regexp.MustCompile(`/api/maze/(?P<x>\d+)x(?P<y>\d+)/(?P<seed>\d+)(?P<stats>/stats)?$`)
 	mux := http.NewServeMux()
 	mux .HandleFunc("/api/maze/", func(w http.ResponseWriter, r *http.Request) {
 		match := maze_path_re.FindStringSubmatch(r.URL.Path)
@@ -163,21 +435,109 @@ func ServerMux() *http.ServeMux {
 		if ss, ok := r.URL.Query()["s"]; ok {
 			scalestr = ss[len(ss)-1]
 		}
+		mr.algo = r.URL.Query().Get("algo")
+		mr.solution = r.URL.Query().Get("solution") == "1"
+		mr.solver = r.URL.Query().Get("solver")
+		mr.animate = r.URL.Query().Get("animate")
+		mr.stats = match[4] != ""
+		mr.format = r.URL.Query().Get("format")
+		if mr.format == "" && accepts(r, "image/png") {
+			mr.format = "png"
+		}
+		if p, err := ParseGrowthPolicy(r.URL.Query().Get("policy")); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, err.Error())
+			return
+		} else {
+			mr.policy = p
+		}
+		if d := r.URL.Query().Get("difficulty"); d != "" {
+			if b, err := ParseDifficultyBand(d); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintln(w, err.Error())
+				return
+			} else {
+				mr.difficulty = &b
+			}
+		}
 		if err := mr.SetFromStrings(match[1], match[2], scalestr, match[3] ); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprintln(w, err.Error())
 			return
 		}
+		requested := mr.seed
 		if mr.seed == 0 {
 			// if we pass the Creator 0, it will generate its own seed.  But we want a consistent URL, so 
 			// we won't allow that.
-			rand.Seed(time.Now().UnixNano())
-			mr.seed = rand.Int63()
+			mr.seed = rand.New(rand.NewSource(time.Now().UnixNano())).Int63()
 			//log.Printf("Got 0 seed; redirecting to random seed %d", mr.seed)
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), generationBudget)
+		defer cancel()
+		if mr.difficulty != nil {
+			// the seed found is its own first try, so its URL is served rather than redirected again
+			seed, err := mr.FindSeed(ctx)
+			var de *DifficultyError
+			if errors.As(err, &de) {
+				writeJSONError(w, http.StatusUnprocessableEntity, err)
+				return
+			} else if err != nil {
+				mr.writeGenerationError(w, err)
+				return
+			}
+			mr.seed = seed
+		}
+		if mr.seed != requested {
 			http.Redirect(w, r, mr.Path(), http.StatusSeeOther)
 			return
 		}
-		mr.RenderSVGMaze(w)
+		// what's drawn depends on Accept when format isn't given
+		w.Header().Add("Vary", "Accept")
+		switch {
+		case mr.stats:
+			mr.RenderStats(ctx, w)
+		case mr.format == "png":
+			mr.RenderMaze(ctx, w, "image/png", func(solution []Coord) Renderer {
+				return &PNGRenderer{dest: w, scale: mr.scale, solution: solution}
+			})
+		case mr.format == "json":
+			mr.RenderMaze(ctx, w, "application/json", func(solution []Coord) Renderer {
+				return &JSONRenderer{dest: w, solution: solution}
+			})
+		case textCharsets[mr.format] != nil:
+			mr.RenderMaze(ctx, w, "text/plain; charset=utf-8", func(solution []Coord) Renderer {
+				return &TextRenderer{dest: w, charset: textCharsets[mr.format], solution: solution}
+			})
+		default:
+			mr.RenderSVGMaze(ctx, w)
+		}
+	})
+	mux.HandleFunc("/api/worksheet.pdf", func(w http.ResponseWriter, r *http.Request) {
+		ws, err := NewWorksheetRequest(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, err.Error())
+			return
+		}
+		if ws.maze.seed == 0 {
+			// like mazes, worksheets get a seed so their URL always makes the same one
+			ws.maze.seed = rand.New(rand.NewSource(time.Now().UnixNano())).Int63()
+			http.Redirect(w, r, ws.Path(), http.StatusSeeOther)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), generationBudget)
+		defer cancel()
+		ws.Render(ctx, w)
+	})
+	mux.HandleFunc("/api/algorithms", func(w http.ResponseWriter, r *http.Request) {
+		writeListing(w, creatorNames(), defaultCreator, func(n string) string {
+			return creators[n].description
+		})
+	})
+	mux.HandleFunc("/api/solvers", func(w http.ResponseWriter, r *http.Request) {
+		writeListing(w, solverNames(), defaultSolver, func(n string) string {
+			return solvers[n].description
+		})
 	})
 	mux.Handle("/webui/", http.FileServer(http.FS(staticfs)))
 	if os.Getenv("DEV") == "true" {
@@ -194,6 +554,13 @@ func ServerMux() *http.ServeMux {
 var mux *http.ServeMux
 var adapter *httpadapter.HandlerAdapter
 func init() {
+	if s := os.Getenv("GENERATION_BUDGET"); s != "" {
+		if d, err := time.ParseDuration(s); err != nil {
+			log.Fatalf("GENERATION_BUDGET %q is not a duration: %s", s, err)
+		} else {
+			generationBudget = d
+		}
+	}
 	mux = ServerMux()
 	if os.Getenv("LAMBDA") == "WEB" {
 		adapter = httpadapter.New(mux)
@@ -5,6 +5,14 @@ var app = new Vue({
    y: 55,
    scale: 25,
    seed: 0, 
+   algo: "walking",
+   policy: "",
+   solution: false,
+   solver: "bfs",
+   animate: "",
+   difficulty: "",
+   algorithms: [],
+   solvers: [],
   },
   methods: {
     randomseed:  function() {
@@ -13,11 +21,45 @@ var app = new Vue({
   },
   computed: {
     svgurl: function() {
-      return "/api/maze/"+
-        this.x+"x"+this.y+"/" + this.seed + "?s="+this.scale
+      var url = "/api/maze/"+
+        this.x+"x"+this.y+"/" + this.seed + "?s="+this.scale +
+        "&algo=" + encodeURIComponent(this.algo)
+      if (this.algo == "growingtree" && this.policy) {
+        url += "&policy=" + encodeURIComponent(this.policy)
+      }
+      if (this.animate) {
+        url += "&animate=" + this.animate
+      } else if (this.solution) {
+        url += "&solution=1"
+      }
+      if (this.difficulty) {
+        url += "&difficulty=" + this.difficulty
+      }
+      if (this.solution || this.animate == "solve") {
+        url += "&solver=" + encodeURIComponent(this.solver)
+      }
+      return url
+    },
+    worksheeturl: function() {
+      var url = "/api/worksheet.pdf?count=10&answers=1&size=" + this.x + "x" + this.y +
+        "&seed=" + this.seed + "&algo=" + encodeURIComponent(this.algo)
+      if (this.algo == "growingtree" && this.policy) {
+        url += "&policy=" + encodeURIComponent(this.policy)
+      }
+      if (this.difficulty) {
+        url += "&difficulty=" + this.difficulty
+      }
+      return url
     },
   },
   mounted: function() {
     this.randomseed()
+    var app = this
+    axios.get("/api/algorithms").then(function(resp) {
+      app.algorithms = resp.data
+    })
+    axios.get("/api/solvers").then(function(resp) {
+      app.solvers = resp.data
+    })
   },
 })
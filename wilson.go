@@ -0,0 +1,46 @@
+package main
+
+import "context"
+
+// WilsonCreator carves a maze with Wilson's algorithm.  Starting from a tree
+// holding only the start room, it takes a random walk from a room outside
+// the tree until the walk hits the tree, erases any loops the walk made, and
+// adds what's left to the tree.  Every spanning tree of the rooms is equally
+// likely, so unlike the other creators its output has no bias toward any
+// particular texture.
+type WilsonCreator struct {
+	seed int64
+}
+
+func (wc *WilsonCreator) Fill(ctx context.Context, grid *Grid, start, finish Coord) (err error) {
+	defer recoverOutOfBounds(&err)
+	rng := newRand(&wc.seed)
+	lt := lattice{g: grid, origin: start}
+	inTree := make([]bool, grid.Len())
+	// exit[i] is the way the walk last left room i; overwriting it when the
+	// walk comes back around is what erases the loops
+	exit := make([]Coord, grid.Len())
+	grid.Update(MakePassable, start)
+	inTree[grid.Idx(start)] = true
+	rooms := lt.rooms()
+	for _, i := range rng.Perm(len(rooms)) {
+		cur := rooms[i]
+		for !inTree[grid.Idx(cur)] {
+			if err := abandoned(ctx); err != nil {
+				return err
+			}
+			ns := lt.neighbors(cur)
+			next := ns[rng.Intn(len(ns))]
+			exit[grid.Idx(cur)] = next
+			cur = next
+		}
+		for cur = rooms[i]; !inTree[grid.Idx(cur)]; cur = exit[grid.Idx(cur)] {
+			lt.join(cur, exit[grid.Idx(cur)])
+			inTree[grid.Idx(cur)] = true
+		}
+	}
+	lt.attach(finish)
+	markEnds(grid, start, finish)
+	grid.note(Event{Step: Step{finish, StepDone}})
+	return nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// WorksheetRequest asks for a PDF of mazes to print, one to a page, with
+// optional answer keys after them
+type WorksheetRequest struct {
+	maze      MazeRequest // the first maze; the others count up from its seed
+	count     int
+	paper     string  // a key of paperSizes
+	landscape bool    // turn the paper sideways
+	margin    float64 // around the page, in millimeters
+	title     string  // at the top of every page
+	answers   bool    // add a page with the solution for each maze
+}
+
+const (
+	maxWorksheetMazes = 50
+	mmPoints          = 72 / 25.4
+	headingHeight     = 40 // points below the margin for the title
+)
+
+var worksheetSizeRe = regexp.MustCompile(`^(\d+)x(\d+)$`)
+
+// NewWorksheetRequest reads a WorksheetRequest from a request's query,
+// filling in defaults for what it doesn't give
+func NewWorksheetRequest(q url.Values) (*WorksheetRequest, error) {
+	ws := &WorksheetRequest{
+		maze:   MazeRequest{x: 30, y: 40, scale: 1},
+		count:  1,
+		paper:  "a4",
+		margin: 15,
+		title:  "Maze",
+	}
+	if s := q.Get("size"); s != "" {
+		match := worksheetSizeRe.FindStringSubmatch(s)
+		if match == nil {
+			return nil, fmt.Errorf("Size invalid: %q is not like 30x40", s)
+		}
+		ws.maze.x, _ = strconv.Atoi(match[1])
+		ws.maze.y, _ = strconv.Atoi(match[2])
+	}
+	for _, n := range []struct {
+		key string
+		set func(string) error
+	}{
+		{"seed", func(s string) (err error) { ws.maze.seed, err = strconv.ParseInt(s, 10, 64); return }},
+		{"count", func(s string) (err error) { ws.count, err = strconv.Atoi(s); return }},
+		{"margin", func(s string) (err error) { ws.margin, err = strconv.ParseFloat(s, 64); return }},
+	} {
+		if s := q.Get(n.key); s != "" {
+			if err := n.set(s); err != nil {
+				return nil, fmt.Errorf("%s invalid: %s is not a number", n.key, s)
+			}
+		}
+	}
+	if p := q.Get("paper"); p != "" {
+		ws.paper = p
+	}
+	switch o := q.Get("orientation"); o {
+	case "", "portrait":
+	case "landscape":
+		ws.landscape = true
+	default:
+		return nil, fmt.Errorf("Orientation invalid: %q is not portrait or landscape", o)
+	}
+	if t, ok := q["title"]; ok {
+		ws.title = t[0]
+	}
+	ws.answers = q.Get("answers") == "1"
+	ws.maze.algo = q.Get("algo")
+	ws.maze.solver = q.Get("solver")
+	var err error
+	if ws.maze.policy, err = ParseGrowthPolicy(q.Get("policy")); err != nil {
+		return nil, err
+	}
+	if d := q.Get("difficulty"); d != "" {
+		b, err := ParseDifficultyBand(d)
+		if err != nil {
+			return nil, err
+		}
+		ws.maze.difficulty = &b
+	}
+	return ws, ws.Validate()
+}
+
+func (ws *WorksheetRequest) Path() string {
+	p := fmt.Sprintf("/api/worksheet.pdf?size=%dx%d&seed=%d&count=%d&paper=%s",
+		ws.maze.x, ws.maze.y, ws.maze.seed, ws.count, url.QueryEscape(ws.paper))
+	if ws.landscape {
+		p += "&orientation=landscape"
+	}
+	p += "&margin=" + pdfNum(ws.margin) + "&title=" + url.QueryEscape(ws.title)
+	if ws.answers {
+		p += "&answers=1"
+	}
+	return p + ws.maze.options()
+}
+
+func (ws *WorksheetRequest) Validate() error {
+	if err := ws.maze.Validate(); err != nil {
+		return err
+	}
+	if ws.count < 1 || ws.count > maxWorksheetMazes {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("count %d is out of bounds, must be between 1 and %d", ws.count, maxWorksheetMazes),
+			nil,
+		}}
+	}
+	size, ok := paperSizes[ws.paper]
+	if !ok {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("No such paper %q; the papers are a4 and letter", ws.paper),
+			nil,
+		}}
+	}
+	// leave at least an inch for the maze.  Written so NaN, which ParseFloat
+	// takes, is out of bounds too.
+	if max := (math.Min(size[0], size[1]) - 72) / 2 / mmPoints; !(ws.margin >= 0 && ws.margin <= max) {
+		return &ParamOutOfBoundsError{&BaseError{
+			fmt.Sprintf("margin %smm is out of bounds, must be between 0 and %smm", pdfNum(ws.margin), pdfNum(max)),
+			nil,
+		}}
+	}
+	if len(ws.title) > 200 {
+		return &ParamOutOfBoundsError{&BaseError{"title is too long; it can be at most 200 bytes", nil}}
+	}
+	return nil
+}
+
+// worksheetMaze is a maze on a worksheet and what to say about it
+type worksheetMaze struct {
+	m        *Maze
+	seed     int64
+	solution []Coord
+}
+
+// Render responds with the worksheet as a PDF: a page for each maze, then an
+// answer key page for each if they were asked for
+func (ws *WorksheetRequest) Render(ctx context.Context, w http.ResponseWriter) {
+	var mazes []worksheetMaze
+	mr := ws.maze
+	for i := 0; i < ws.count; i++ {
+		if mr.difficulty != nil {
+			seed, err := mr.FindSeed(ctx)
+			var de *DifficultyError
+			if errors.As(err, &de) {
+				writeJSONError(w, http.StatusUnprocessableEntity, err)
+				return
+			} else if err != nil {
+				mr.writeGenerationError(w, err)
+				return
+			}
+			mr.seed = seed
+		}
+		m, err := mr.Generate(ctx)
+		if err != nil {
+			mr.writeGenerationError(w, err)
+			return
+		}
+		wm := worksheetMaze{m: m, seed: mr.seed}
+		if ws.answers {
+			var ok bool
+			if wm.solution, ok = mr.solve(w, m); !ok {
+				return
+			}
+		}
+		mazes = append(mazes, wm)
+		mr.seed = mr.seed%math.MaxInt64 + 1 // seeds are positive
+	}
+	size := paperSizes[ws.paper]
+	doc := &pdfDoc{width: size[0], height: size[1]}
+	if ws.landscape {
+		doc.width, doc.height = doc.height, doc.width
+	}
+	for i, wm := range mazes {
+		ws.drawPage(doc.NewPage(), ws.title, i, wm.seed, wm.m, nil)
+	}
+	if ws.answers {
+		for i, wm := range mazes {
+			ws.drawPage(doc.NewPage(), "Answer key: "+ws.title, i, wm.seed, wm.m, wm.solution)
+		}
+	}
+	w.Header().Add("Content-Type", "application/pdf")
+	w.Header().Add("Content-Disposition", `inline; filename="worksheet.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := doc.WriteTo(w); err != nil {
+		log.Printf("Writing %s: %s", ws.Path(), err)
+	}
+}
+
+// drawPage draws the ith maze of the worksheet on page p under a heading,
+// as big as it fits inside the margins, with solution over it if set
+func (ws *WorksheetRequest) drawPage(p *pdfPage, title string, i int, seed int64, m *Maze, solution []Coord) {
+	margin := ws.margin * mmPoints
+	p.Fill(0, 0, 0)
+	p.Text(margin, margin+18, 18, title)
+	p.Text(margin, margin+32, 10, fmt.Sprintf("Maze %d of %d, %dx%d, seed %d", i+1, ws.count, m.x, m.y, seed))
+	// the maze has a border one location wide, like the SVG
+	boxW, boxH := p.doc.width-2*margin, p.doc.height-2*margin-headingHeight
+	cell := math.Min(boxW/float64(m.x+2), boxH/float64(m.y+2))
+	left := margin + (boxW-cell*float64(m.x+2))/2
+	top := margin + headingHeight
+	pos := func(x, y int) (float64, float64) {
+		return left + float64(x+1)*cell, top + float64(y+1)*cell
+	}
+	// the walls, a row at a time, in runs so there are fewer rectangles.  Each
+	// overlaps the next row a little so viewers don't show seams between them.
+	for y := -1; y <= m.y; y++ {
+		from, inRun := 0, false
+		for x := -1; x <= m.x+1; x++ {
+			// off the grid is the border, except m.x+1, which ends the last run
+			l, err := m.At(x, y)
+			wall := x <= m.x && (err != nil || !l.Passable)
+			if wall && !inRun {
+				from, inRun = x, true
+			} else if !wall && inRun {
+				rx, ry := pos(from, y)
+				p.Rect(rx, ry, float64(x-from)*cell, cell+0.25)
+				inRun = false
+			}
+		}
+	}
+	if len(solution) > 0 {
+		xs, ys := make([]float64, len(solution)), make([]float64, len(solution))
+		for i, c := range solution {
+			xs[i], ys[i] = pos(c.X, c.Y)
+			xs[i], ys[i] = xs[i]+cell/2, ys[i]+cell/2
+		}
+		p.Stroke(0.86, 0.08, 0.24, cell/3)
+		p.Polyline(xs, ys)
+	}
+	size := cell * 0.6
+	for _, end := range []struct {
+		flag uint
+		msg  string
+	}{{Start, "S"}, {Finish, "F"}} {
+		for i := 0; i < m.grid.Len(); i++ {
+			if l := m.grid.AtIdx(i); l.Special&end.flag != 0 {
+				x, y := pos(l.X, l.Y)
+				p.Text(x+(cell-size*0.6)/2, y+(cell+size*0.7)/2, size, end.msg)
+			}
+		}
+	}
+}